@@ -0,0 +1,13 @@
+package main
+
+import (
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/pelletier/go-toml"
+)
+
+func main() {
+	r := mux.NewRouter()
+	t, _ := toml.Load(`repo = "github.com/d2fn/gopack"`)
+	fmt.Println(r, t)
+}
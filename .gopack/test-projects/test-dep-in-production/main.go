@@ -0,0 +1,10 @@
+package main
+
+import (
+	"fmt"
+	"launchpad.net/gocheck"
+)
+
+func main() {
+	fmt.Println(gocheck.Equals)
+}
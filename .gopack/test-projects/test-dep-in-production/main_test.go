@@ -0,0 +1,8 @@
+package main
+
+import (
+	"launchpad.net/gocheck"
+	"testing"
+)
+
+func Test(t *testing.T) { gocheck.TestingT(t) }
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	Blue     = uint8(94)
+	Green    = uint8(92)
+	Red      = uint8(31)
+	Gray     = uint8(90)
+	Yellow   = uint8(93)
+	EndColor = "\033[0m"
+)
+
+// When to color the output, set with --color.
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+)
+
+var (
+	colorModes = []string{colorAuto, colorAlways, colorNever}
+	colorMode  = colorAuto
+)
+
+func validColorMode(mode string) bool {
+	for _, m := range colorModes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// Whether what's written to w is colored. Every output of gp goes
+// through here, so the decision is the same for all of them.
+func useColor(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return wantColor(ok && isTerminal(f))
+}
+
+// Colors are used when asked for, and otherwise only on terminals
+// that support them, unless the environment turns them off.
+func wantColor(terminal bool) bool {
+	switch colorMode {
+	case colorAlways:
+		return true
+	case colorNever:
+		return false
+	}
+
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" || os.Getenv("GOPACK_SKIP_COLORS") == "1" {
+		return false
+	}
+	return terminal
+}
+
+func isTerminal(f *os.File) bool {
+	stat, err := f.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
+}
+
+func fprintcolor(w io.Writer, c uint8, s string, args ...interface{}) {
+	color := useColor(w)
+	if color {
+		fmt.Fprintf(w, "\033[%dm", c)
+	}
+
+	if len(args) > 0 {
+		fmt.Fprintf(w, s, args...)
+	} else {
+		fmt.Fprint(w, s)
+	}
+
+	if color {
+		fmt.Fprint(w, EndColor)
+	}
+}
@@ -0,0 +1,389 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/d2fn/gopack/config"
+	"github.com/d2fn/gopack/deps"
+	"github.com/d2fn/gopack/stats"
+	"github.com/d2fn/gopack/workspace"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// A command gp runs itself rather than passing it to the go command.
+type command struct {
+	name string
+	// positional arguments, shown in the usage
+	args    string
+	summary string
+	flags   []flagDef
+	needs   requirement
+	// what needs becomes when the flags given use less of the
+	// project, or more, needs itself when nil
+	needsWith func(opts options) requirement
+	run       func(p *project, opts options, args []string) error
+}
+
+// How much of the project a command needs loaded before it runs.
+// Each requirement includes the ones before it.
+type requirement int
+
+const (
+	// nothing at all, like help
+	standalone requirement = iota
+	// the environment of the go command, without reading gopack.config,
+	// so that a broken one doesn't get in the way
+	environment
+	// gopack.config
+	configured
+	// the analysis of the source, without fetching anything
+	analyzed
+	// the dependencies fetched and validated against the source
+	resolved
+)
+
+// Commands of the go tool that only print information, run without the
+// dependencies. Any other gets them ready first, as it may build the code.
+var goMetadataCommands = map[string]bool{
+	"doc":     true,
+	"env":     true,
+	"fmt":     true,
+	"help":    true,
+	"version": true,
+}
+
+// A flag of gp or of one of its commands.
+type flagDef struct {
+	name string
+	// name of the value the flag takes, empty for boolean flags
+	value string
+	usage string
+}
+
+// Flags set in the command line, by name. Boolean flags are "true".
+type options map[string]string
+
+func (o options) bool(name string) bool {
+	return o[name] == "true"
+}
+
+// What the commands run against, loaded as far as they need.
+type project struct {
+	// empty for commands run where there's no gopack.config
+	config *config.Config
+	stats  *stats.ProjectStats
+	// fetched only if they NeedsFetch, as something changed since the last run
+	deps *deps.Dependencies
+	// nil unless the project is part of a workspace
+	workspace *workspace.Workspace
+}
+
+// Configurations whose dependencies the project shares the vendor
+// tree with: its own, or the ones of its workspace.
+func (p *project) configs() []*config.Config {
+	if p.workspace != nil {
+		return p.workspace.Configs
+	}
+	return []*config.Config{p.config}
+}
+
+var globalFlags = []flagDef{
+	{name: "config", value: "dir", usage: "run in dir, the directory holding gopack.config"},
+	{name: "strict", usage: "fail on warnings, like dependencies pointing at a branch"},
+	{name: "color", value: "when", usage: "color the output: auto, when printing to a terminal, always or never"},
+	{name: "no-color", usage: "same as --color=never"},
+	{name: "quiet", usage: "print only warnings and errors"},
+	{name: "verbose", usage: "print the commands gp runs"},
+	{name: "debug", usage: "print why gp does what it does too"},
+	{name: "events", value: "file", usage: "append what gp does to file, as lines of JSON"},
+}
+
+// Commands of the go tool offered by completion. Any other is passed to it too.
+var goCommands = []string{
+	"build", "clean", "doc", "env", "fix", "fmt", "generate", "get",
+	"install", "list", "run", "test", "tool", "version", "vet",
+}
+
+// Defined in init, as help and completion refer back to them.
+var commands []*command
+
+func init() {
+	commands = []*command{
+		{
+			name:    "help",
+			args:    "[command]",
+			summary: "show the help of gp or of one of its commands",
+			needs:   standalone,
+			run:     runHelp,
+		},
+		{
+			name:    "dependencytree",
+			summary: "print the tree of dependencies",
+			needs:   configured,
+			run:     runDependencyTree,
+		},
+		{
+			name:    "stats",
+			summary: "show statistics about the imports of the project",
+			flags: []flagDef{
+				{name: "packages", usage: "group the imports by package of the project"},
+				{name: "symbols", usage: "list the identifiers used from every remote package"},
+				{name: "history", usage: "follow the dependencies across the git history"},
+				{name: "samples", value: "n", usage: fmt.Sprintf("revisions --history looks at (default %d)", defaultHistorySamples)},
+				{name: "csv", usage: "print --history as a row per dependency and revision"},
+			},
+			needs: analyzed,
+			// the history analyzes every revision on its own
+			needsWith: func(opts options) requirement {
+				if opts.bool("history") {
+					return configured
+				}
+				return analyzed
+			},
+			run: runStats,
+		},
+		{
+			name:    "vendor",
+			summary: "copy the dependencies to the vendor directory",
+			flags: []flagDef{
+				{name: "verify", usage: "check the copy wasn't modified since it was made"},
+				{name: "prune", usage: "trim .gopack/vendor down to the packages the project imports"},
+				{name: "drop-tests", usage: "remove tests and testdata when pruning"},
+				{name: "drop-assets", usage: "remove everything but sources when pruning"},
+			},
+			needs: configured,
+			// only pruning looks at the imports of the source
+			needsWith: func(opts options) requirement {
+				if opts.bool("prune") {
+					return analyzed
+				}
+				return configured
+			},
+			run: runVendor,
+		},
+		{
+			name:    "export",
+			summary: "translate the dependencies to another format",
+			flags: []flagDef{
+				{name: "gomod", usage: "write a go.mod and a go.sum"},
+				{name: "force", usage: "overwrite an existing go.mod and go.sum"},
+			},
+			needs: configured,
+			run:   runExport,
+		},
+		{
+			name:    "import",
+			args:    "<file>",
+			summary: "write the gopack.config equivalent to another manager's manifest",
+			flags: []flagDef{
+				{name: "force", usage: "overwrite an existing gopack.config"},
+			},
+			// there's no gopack.config to load before importing one
+			needs: standalone,
+			run:   runImport,
+		},
+		{
+			name:    "completion",
+			args:    "bash|zsh",
+			summary: "print the completion script of a shell",
+			needs:   standalone,
+			run:     runCompletion,
+		},
+	}
+}
+
+// The go command run with the arguments as they are.
+func goCommand(name string) *command {
+	needs := resolved
+	if goMetadataCommands[name] {
+		needs = environment
+	}
+	return &command{name: name, needs: needs, run: runGo}
+}
+
+// How much of the project the command needs with the flags given.
+func (cmd *command) requires(opts options) requirement {
+	if cmd.needsWith != nil {
+		return cmd.needsWith(opts)
+	}
+	return cmd.needs
+}
+
+func lookupCommand(name string) *command {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// An invalid command line, reported along with the usage of the command.
+type usageError struct {
+	cmd *command
+	err error
+}
+
+func (e *usageError) Error() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s\n\n", e.err)
+	if e.cmd == nil {
+		printUsage(&buf)
+	} else {
+		e.cmd.printUsage(&buf)
+	}
+	return strings.TrimRight(buf.String(), "\n")
+}
+
+var errNoCommand = errors.New("no command given")
+
+// Parse the flags of gp that come before the command, returning the
+// command line left and whether -- forces it to go to the go command.
+func parseFlags(args []string) ([]string, bool, error) {
+	fs := newFlagSet("gp", globalFlags)
+	if err := fs.Parse(args); err != nil {
+		return nil, false, err
+	}
+	opts := setFlags(fs)
+	rest := fs.Args()
+
+	strict = opts.bool("strict")
+	configDir = opts["config"]
+	if opts.bool("no-color") {
+		colorMode = colorNever
+	} else if mode := opts["color"]; mode != "" {
+		if !validColorMode(mode) {
+			return nil, false, fmt.Errorf("invalid --color %s, expected one of %s", mode, strings.Join(colorModes, ", "))
+		}
+		colorMode = mode
+	}
+
+	levels := 0
+	for name, l := range map[string]logLevel{"quiet": levelQuiet, "verbose": levelVerbose, "debug": levelDebug} {
+		if opts.bool(name) {
+			level = l
+			levels++
+		}
+	}
+	if levels > 1 {
+		return nil, false, errors.New("only one of --quiet, --verbose and --debug may be given")
+	}
+
+	// before --config changes the directory it's relative to
+	if path := opts["events"]; path != "" {
+		if err := openEvents(path); err != nil {
+			return nil, false, err
+		}
+	}
+
+	passthrough := len(rest) < len(args) && args[len(args)-len(rest)-1] == "--"
+	return rest, passthrough, nil
+}
+
+// Parse the flags of the command, returning its arguments.
+func (cmd *command) parse(args []string) (options, []string, error) {
+	fs := newFlagSet("gp "+cmd.name, cmd.flags)
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+	return setFlags(fs), fs.Args(), nil
+}
+
+func newFlagSet(name string, defs []flagDef) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	// errors are reported along with our own usage
+	fs.SetOutput(ioutil.Discard)
+	for _, f := range defs {
+		if f.value != "" {
+			fs.String(f.name, "", f.usage)
+		} else {
+			fs.Bool(f.name, false, f.usage)
+		}
+	}
+	return fs
+}
+
+func setFlags(fs *flag.FlagSet) options {
+	opts := options{}
+	fs.Visit(func(f *flag.Flag) {
+		opts[f.Name] = f.Value.String()
+	})
+	return opts
+}
+
+func runHelp(p *project, opts options, args []string) error {
+	if len(args) == 0 {
+		printUsage(os.Stdout)
+		return nil
+	}
+
+	cmd := lookupCommand(args[0])
+	if cmd == nil {
+		return fmt.Errorf("unknown command %s, run gp help for the list of commands or go help %s if it's the go command's", args[0], args[0])
+	}
+	cmd.printUsage(os.Stdout)
+	return nil
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprint(w, `gp runs the go command with the dependencies declared in gopack.config in place.
+
+Usage:
+
+	gp [flags] <command> [arguments]
+	gp [flags] -- <go command> [arguments]
+
+Commands:
+
+`)
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	for _, cmd := range commands {
+		fmt.Fprintf(tw, "\t%s\t%s\n", cmd.name, cmd.summary)
+	}
+	tw.Flush()
+
+	fmt.Fprint(w, `
+Any other command, like build, test or run, goes to the go command once the
+dependencies are in place. Everything after -- goes to the go command too,
+even if gp has a command of the same name.
+
+Flags:
+
+`)
+	printFlags(w, globalFlags)
+	fmt.Fprint(w, "\nRun gp help <command> for the flags of a command.\n")
+}
+
+func (cmd *command) printUsage(w io.Writer) {
+	usage := "gp " + cmd.name
+	if len(cmd.flags) > 0 {
+		usage += " [flags]"
+	}
+	if cmd.args != "" {
+		usage += " " + cmd.args
+	}
+	fmt.Fprintf(w, "Usage: %s\n\n%s.\n", usage, strings.ToUpper(cmd.summary[:1])+cmd.summary[1:])
+
+	if len(cmd.flags) > 0 {
+		fmt.Fprint(w, "\nFlags:\n\n")
+		printFlags(w, cmd.flags)
+	}
+}
+
+func printFlags(w io.Writer, defs []flagDef) {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	for _, f := range defs {
+		name := "--" + f.name
+		if f.value != "" {
+			name += "=" + f.value
+		}
+		fmt.Fprintf(tw, "\t%s\t%s\n", name, f.usage)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+var completions = map[string]func(w io.Writer){
+	"bash": bashCompletion,
+	"zsh":  zshCompletion,
+}
+
+func runCompletion(p *project, opts options, args []string) error {
+	if len(args) == 1 {
+		if complete, found := completions[args[0]]; found {
+			complete(os.Stdout)
+			return nil
+		}
+	}
+	return &usageError{cmd: lookupCommand("completion"), err: fmt.Errorf("expected the shell, one of bash, zsh")}
+}
+
+func commandNames() []string {
+	names := []string{}
+	for _, cmd := range commands {
+		names = append(names, cmd.name)
+	}
+	return names
+}
+
+func flagNames(defs []flagDef) []string {
+	names := make([]string, len(defs))
+	for i, f := range defs {
+		names[i] = "--" + f.name
+	}
+	return names
+}
+
+// Global flags that take their value from the next word.
+func valueFlags() []string {
+	names := []string{}
+	for _, f := range globalFlags {
+		if f.value != "" {
+			names = append(names, "--"+f.name, "-"+f.name)
+		}
+	}
+	return names
+}
+
+// Source it, or save it in a bash_completion.d directory.
+func bashCompletion(w io.Writer) {
+	fmt.Fprintf(w, `# bash completion for gp, generated by gp completion bash
+_gp() {
+	local cur=${COMP_WORDS[COMP_CWORD]}
+	local cmd="" i
+	for ((i = 1; i < COMP_CWORD; i++)); do
+		case ${COMP_WORDS[i]} in
+		%s) ((i++)) ;;
+		--) cmd=go; break ;;
+		-*) ;;
+		*) cmd=${COMP_WORDS[i]}; break ;;
+		esac
+	done
+
+	case $cmd in
+	"")
+		if [[ $cur == -* ]]; then
+			COMPREPLY=($(compgen -W "%s" -- "$cur"))
+		else
+			COMPREPLY=($(compgen -W "%s" -- "$cur"))
+		fi
+		;;
+`, strings.Join(valueFlags(), "|"), strings.Join(flagNames(globalFlags), " "), strings.Join(append(commandNames(), goCommands...), " "))
+
+	for _, cmd := range commands {
+		switch {
+		case cmd.name == "help":
+			fmt.Fprintf(w, "\t%s)\n\t\tCOMPREPLY=($(compgen -W \"%s\" -- \"$cur\"))\n\t\t;;\n", cmd.name, strings.Join(commandNames(), " "))
+		case cmd.name == "completion":
+			fmt.Fprintf(w, "\t%s)\n\t\tCOMPREPLY=($(compgen -W \"bash zsh\" -- \"$cur\"))\n\t\t;;\n", cmd.name)
+		case len(cmd.flags) > 0:
+			fmt.Fprintf(w, "\t%s)\n\t\tCOMPREPLY=($(compgen -W \"%s\" -- \"$cur\"))\n", cmd.name, strings.Join(flagNames(cmd.flags), " "))
+			if cmd.args != "" {
+				fmt.Fprint(w, "\t\t[[ $cur == -* ]] || COMPREPLY=($(compgen -f -- \"$cur\"))\n")
+			}
+			fmt.Fprint(w, "\t\t;;\n")
+		}
+	}
+
+	fmt.Fprint(w, `	*)
+		COMPREPLY=($(compgen -f -- "$cur"))
+		;;
+	esac
+}
+complete -F _gp gp
+`)
+}
+
+// Save it as _gp in a directory of your fpath.
+func zshCompletion(w io.Writer) {
+	fmt.Fprint(w, "#compdef gp\n# zsh completion for gp, generated by gp completion zsh\n\n_gp() {\n\tlocal -a commands\n\tcommands=(\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "\t\t%q\n", cmd.name+":"+cmd.summary)
+	}
+	for _, name := range goCommands {
+		fmt.Fprintf(w, "\t\t%q\n", name+":run go "+name)
+	}
+
+	fmt.Fprintf(w, `	)
+
+	local cmd="" i
+	for ((i = 2; i < CURRENT; i++)); do
+		case ${words[i]} in
+		%s) ((i++)) ;;
+		--) cmd=go; break ;;
+		-*) ;;
+		*) cmd=${words[i]}; break ;;
+		esac
+	done
+
+	case $cmd in
+	"")
+		if [[ $PREFIX == -* ]]; then
+			compadd -- %s
+		else
+			_describe -t commands 'gp commands' commands
+		fi
+		;;
+`, strings.Join(valueFlags(), "|"), strings.Join(flagNames(globalFlags), " "))
+
+	for _, cmd := range commands {
+		switch {
+		case cmd.name == "help":
+			fmt.Fprintf(w, "\t%s)\n\t\tcompadd -- %s\n\t\t;;\n", cmd.name, strings.Join(commandNames(), " "))
+		case cmd.name == "completion":
+			fmt.Fprintf(w, "\t%s)\n\t\tcompadd -- bash zsh\n\t\t;;\n", cmd.name)
+		case len(cmd.flags) > 0:
+			fmt.Fprintf(w, "\t%s)\n\t\tcompadd -- %s\n", cmd.name, strings.Join(flagNames(cmd.flags), " "))
+			if cmd.args != "" {
+				fmt.Fprint(w, "\t\t_files\n")
+			}
+			fmt.Fprint(w, "\t\t;;\n")
+		}
+	}
+
+	fmt.Fprint(w, `	*)
+		_files
+		;;
+	esac
+}
+
+_gp "$@"
+`)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"github.com/d2fn/gopack"
+	"github.com/d2fn/gopack/config"
+	"go/build"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Compose the environment of the go commands gp runs out of the
+// user's one, environ, leaving gopack's own process untouched.
+// GOPATH points at the vendor tree, the workspace's if there's one,
+// followed by the user's GOPATH when the configuration appends it.
+// Modules are turned off, gopack vendors in GOPATH mode, and the
+// variables of its env table are set last.
+func goEnv(c *config.Config, environ []string) []string {
+	gopath := filepath.Join(root, gopack.VendorDir)
+	if c.AppendGopath {
+		user := lookupEnv(environ, "GOPATH")
+		if user == "" {
+			user = build.Default.GOPATH
+		}
+		if user != "" {
+			gopath += string(os.PathListSeparator) + user
+		}
+	}
+	env := setEnv(environ, "GOPATH", gopath)
+	env = setEnv(env, "GO111MODULE", "off")
+
+	keys := make([]string, 0, len(c.Env))
+	for k := range c.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		env = setEnv(env, k, c.Env[k])
+	}
+	return env
+}
+
+func lookupEnv(env []string, key string) string {
+	for _, kv := range env {
+		if strings.HasPrefix(kv, key+"=") {
+			return strings.TrimPrefix(kv, key+"=")
+		}
+	}
+	return ""
+}
+
+// Set key in a copy of env, replacing its previous value.
+func setEnv(env []string, key, value string) []string {
+	composed := make([]string, 0, len(env)+1)
+	for _, kv := range env {
+		if !strings.HasPrefix(kv, key+"=") {
+			composed = append(composed, kv)
+		}
+	}
+	return append(composed, key+"="+value)
+}
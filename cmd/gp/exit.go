@@ -0,0 +1,52 @@
+package main
+
+import (
+	"github.com/d2fn/gopack"
+	"os/exec"
+)
+
+// Exit codes, the go command's own exit code is used when it fails.
+const (
+	ExitFailure    = 1
+	ExitConfig     = 2
+	ExitValidation = 3
+	ExitFetch      = 4
+)
+
+var exitCodes = map[string]int{
+	gopack.UnusedDep:        ExitValidation,
+	gopack.UnmanagedImport:  ExitValidation,
+	gopack.FloatingDep:      ExitValidation,
+	gopack.TestDepInProd:    ExitValidation,
+	gopack.MissingPackage:   ExitValidation,
+	gopack.InvalidCheckout:  ExitConfig,
+	gopack.ConflictingDep:   ExitConfig,
+	gopack.InvalidConfig:    ExitConfig,
+	gopack.MissingImport:    ExitConfig,
+	gopack.UnknownKey:       ExitConfig,
+	gopack.InvalidType:      ExitConfig,
+	gopack.InvalidValue:     ExitConfig,
+	gopack.DuplicateImport:  ExitConfig,
+	gopack.InvalidWorkspace: ExitConfig,
+	gopack.FetchFailed:      ExitFetch,
+}
+
+// Map an error to the exit code of the process.
+// Validation errors are reported with the code of the first one.
+func ExitCode(err error) int {
+	switch e := err.(type) {
+	case *gopack.ProjectError:
+		if code, found := exitCodes[e.Kind]; found {
+			return code
+		}
+	case gopack.ProjectErrors:
+		if len(e) > 0 {
+			return ExitCode(e[0])
+		}
+	case *exec.ExitError:
+		if code := e.ExitCode(); code > 0 {
+			return code
+		}
+	}
+	return ExitFailure
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/d2fn/gopack"
+	"io"
+	"os"
+	"time"
+)
+
+// How much gopack tells about what it does. Its messages go to stderr,
+// leaving stdout to the output of commands and the programs gp runs.
+type logLevel int
+
+const (
+	// only warnings and errors
+	levelQuiet logLevel = iota
+	// progress too, like the dependencies being updated
+	levelNormal
+	// the commands run too
+	levelVerbose
+	// and the decisions behind them
+	levelDebug
+)
+
+var (
+	level               = levelNormal
+	logOutput io.Writer = os.Stderr
+	// JSON lines written to the file given by --events, nil without it
+	events *json.Encoder
+)
+
+// Something gopack did, written as a line of JSON for tools to follow.
+type event struct {
+	Time  time.Time `json:"time"`
+	Event string    `json:"event"`
+	// the dependency, for fetch and checkout events
+	Import   string `json:"import,omitempty"`
+	Checkout string `json:"checkout,omitempty"`
+	Spec     string `json:"spec,omitempty"`
+	// the kind of project error, for validation events
+	Kind     string `json:"kind,omitempty"`
+	Position string `json:"position,omitempty"`
+	Warning  bool   `json:"warning,omitempty"`
+	// the command line, for command events
+	Command string `json:"command,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Event names.
+const (
+	fetchEvent      = "fetch"
+	checkoutEvent   = "checkout"
+	validationEvent = "validation"
+	commandEvent    = "command"
+)
+
+// Start writing events to the file at path.
+func openEvents(path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	events = json.NewEncoder(f)
+	return nil
+}
+
+func emit(e event) {
+	if events == nil {
+		return
+	}
+	e.Time = time.Now().UTC()
+	if err := events.Encode(e); err != nil {
+		// keep going, the events are only informative
+		events = nil
+		warnf("unable to write events: %s\n", err)
+	}
+}
+
+// Emit the outcome of something done to a dependency.
+func depEvent(name, importPath, checkout, spec string, err error) {
+	e := event{Event: name, Import: importPath, Checkout: checkout, Spec: spec}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	emit(e)
+}
+
+func errorEvent(e *gopack.ProjectError) {
+	ev := event{Event: validationEvent, Kind: e.Kind, Warning: e.Warning, Error: e.Message}
+	if e.Position.IsValid() {
+		ev.Position = e.Position.String()
+	}
+	emit(ev)
+}
+
+func logf(l logLevel, c uint8, s string, args ...interface{}) {
+	if l <= level {
+		fprintcolor(logOutput, c, s, args...)
+	}
+}
+
+// Print the progress of gp, unless it's quiet.
+func progress(s string, args ...interface{}) {
+	logf(levelNormal, Gray, s, args...)
+}
+
+// Print what gp is done with.
+func infof(s string, args ...interface{}) {
+	logf(levelNormal, Green, s, args...)
+}
+
+func verbosef(s string, args ...interface{}) {
+	logf(levelVerbose, Gray, s, args...)
+}
+
+func debugf(s string, args ...interface{}) {
+	logf(levelDebug, Blue, s, args...)
+}
+
+// Warnings are printed even when gp is quiet.
+func warnf(s string, args ...interface{}) {
+	logf(levelQuiet, Yellow, "warning: "+s, args...)
+}
@@ -0,0 +1,557 @@
+// Command gp runs the go command against the dependencies
+// declared in the project's gopack.config.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/d2fn/gopack"
+	"github.com/d2fn/gopack/config"
+	"github.com/d2fn/gopack/deps"
+	"github.com/d2fn/gopack/export"
+	"github.com/d2fn/gopack/history"
+	"github.com/d2fn/gopack/manifests"
+	"github.com/d2fn/gopack/prune"
+	"github.com/d2fn/gopack/stats"
+	"github.com/d2fn/gopack/workspace"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Revisions gp stats --history looks at by default.
+const defaultHistorySamples = 20
+
+var (
+	pwd string
+	// directory whose .gopack/vendor holds the dependencies:
+	// pwd, or the root of the workspace it belongs to
+	root string
+	// environment of the go commands, see goEnv
+	env []string
+	// fail on warnings, like dependencies pointing at a branch
+	strict = false
+	// directory gp runs in, given by --config
+	configDir string
+)
+
+func main() {
+	if err := run(); err != nil {
+		printError(err)
+		os.Exit(ExitCode(err))
+	}
+}
+
+func run() error {
+	args, passthrough, err := parseFlags(os.Args[1:])
+	if err == flag.ErrHelp {
+		printUsage(os.Stdout)
+		return nil
+	} else if err != nil {
+		return &usageError{err: err}
+	}
+	if len(args) == 0 {
+		return &usageError{err: errNoCommand}
+	}
+
+	cmd := lookupCommand(args[0])
+	opts, cmdArgs := options{}, args
+	if cmd == nil || passthrough {
+		cmd = goCommand(args[0])
+	} else {
+		opts, cmdArgs, err = cmd.parse(args[1:])
+		if err == flag.ErrHelp {
+			cmd.printUsage(os.Stdout)
+			return nil
+		} else if err != nil {
+			return &usageError{cmd: cmd, err: err}
+		}
+	}
+
+	if configDir != "" {
+		if err := os.Chdir(configDir); err != nil {
+			return err
+		}
+	}
+	err = setPwd()
+	if err != nil {
+		return err
+	}
+
+	p, err := loadProject(cmd, opts)
+	if err != nil {
+		return err
+	}
+	return cmd.run(p, opts, cmdArgs)
+}
+
+// Load as much of the project as the command needs: the configuration,
+// the analysis of the source and the dependencies, ready and validated.
+func loadProject(cmd *command, opts options) (*project, error) {
+	p := &project{}
+	needs := cmd.requires(opts)
+	if needs == standalone {
+		return p, nil
+	}
+
+	// commands like go version also run outside of projects,
+	// and in projects whose configuration is broken
+	if needs == environment {
+		p.config = &config.Config{}
+		env = goEnv(p.config, os.Environ())
+		return p, nil
+	}
+
+	var err error
+	if p.config, err = config.NewConfig("."); err != nil {
+		return nil, err
+	}
+
+	w, err := workspace.Find(pwd)
+	if err != nil {
+		return nil, err
+	}
+	if w != nil {
+		debugf("%s is part of the workspace in %s\n", pwd, w.Root)
+		p.workspace, p.config, root = w, w.Config(pwd), w.Root
+	}
+	env = goEnv(p.config, os.Environ())
+	if needs < analyzed {
+		return p, nil
+	}
+
+	c := p.config
+	ps, err := stats.Analyze(".", stats.Options{
+		Platforms:  c.Platforms,
+		Tags:       c.Tags,
+		Ignore:     c.Ignore,
+		Cache:      filepath.Join(pwd, gopack.GopackStatsCache),
+		Repository: c.Repository,
+		Managed:    c.DepImports(),
+		Symbols:    cmd.name == "stats" && opts.bool("symbols"),
+		Env:        env,
+	})
+	if err != nil {
+		return nil, err
+	}
+	p.stats = ps
+	if needs < resolved {
+		return p, nil
+	}
+
+	// test dependencies are only needed by the go test command
+	p.deps, err = loadDependencies(p, cmd.name == "test")
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Print the dependencies declared so far, without fetching
+// the ones whose own dependencies are still unknown.
+func runDependencyTree(p *project, opts options, args []string) error {
+	graph := deps.NewGraph()
+	if err := linkProjects(p, graph); err != nil {
+		return err
+	}
+	resolved, err := deps.Resolve(p.config, root, graph)
+	if err != nil {
+		return err
+	}
+	for _, dep := range resolved {
+		graph.Insert(dep)
+	}
+
+	dependencies := &deps.Dependencies{ImportGraph: graph}
+	dependencies.PrintDependencyTree()
+	return nil
+}
+
+func runStats(p *project, opts options, args []string) error {
+	if opts.bool("history") {
+		return printHistory(opts)
+	} else if opts.bool("packages") {
+		p.stats.PrintPackageSummary(os.Stdout)
+	} else if opts.bool("symbols") {
+		p.stats.PrintSymbolSummary(os.Stdout)
+	} else {
+		p.stats.PrintSummary(os.Stdout)
+	}
+	return nil
+}
+
+// Print the dependency history of the project, sampling
+// the number of revisions given by --samples.
+func printHistory(opts options) error {
+	samples := defaultHistorySamples
+	if value := opts["samples"]; value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid --samples %s", value)
+		}
+		samples = n
+	}
+
+	timeline, err := history.Analyze(pwd, samples, env)
+	if err != nil {
+		return err
+	}
+	for _, e := range timeline.Skipped {
+		warnf("left out revision %s\n", e)
+	}
+	if opts.bool("csv") {
+		return timeline.WriteCSV(os.Stdout)
+	}
+	timeline.Print(os.Stdout)
+	return nil
+}
+
+// Copy the dependencies to the vendor directory, check
+// the copy or prune the vendor tree, depending on the flags.
+func runVendor(p *project, opts options, args []string) error {
+	if opts.bool("prune") {
+		if p.workspace != nil {
+			return fmt.Errorf("can't prune the vendor tree of the workspace in %s, the other projects may need what this one doesn't", p.workspace.Root)
+		}
+		return pruneVendor(p.stats, opts)
+	}
+
+	if opts.bool("verify") {
+		modified, err := export.Verify(pwd)
+		if err != nil {
+			return err
+		}
+		if len(modified) > 0 {
+			return fmt.Errorf("modified in %s since it was exported: %s", gopack.ExportDir, strings.Join(modified, ", "))
+		}
+		infof("%s matches %s\n", gopack.ExportDir, gopack.ExportManifest)
+		return nil
+	}
+
+	dependencies, err := resolveDependencies(p)
+	if err != nil {
+		return err
+	}
+	manifest, err := export.Vendor(pwd, dependencies)
+	if err != nil {
+		return err
+	}
+	if len(manifest.Skipped) > 0 {
+		warnf("left out %s, only tests need them and they haven't been fetched, run gp test to vendor them\n", strings.Join(manifest.Skipped, ", "))
+	}
+	infof("copied %d dependencies to %s\n", len(manifest.Deps), gopack.ExportDir)
+	return nil
+}
+
+// Translate the dependencies to a go.mod and a go.sum.
+func runExport(p *project, opts options, args []string) error {
+	if !opts.bool("gomod") {
+		return &usageError{cmd: lookupCommand("export"), err: errors.New("no format given")}
+	}
+
+	for _, file := range []string{export.GoModFile, export.GoSumFile} {
+		if _, err := os.Stat(filepath.Join(pwd, file)); err == nil && !opts.bool("force") {
+			return fmt.Errorf("%s already exists, use --force to overwrite it", file)
+		}
+	}
+
+	c := p.config
+	dependencies, err := resolveDependencies(p)
+	if err != nil {
+		return err
+	}
+	requirements, err := export.WriteGoMod(pwd, c.Repository, dependencies, c.DepImports())
+	if err != nil {
+		return err
+	}
+	infof("wrote %s requiring %d modules and %s\n", export.GoModFile, len(requirements), export.GoSumFile)
+	return nil
+}
+
+// Write the gopack.config equivalent to the manifest of another
+// dependency manager, warning about what can't be translated.
+func runImport(p *project, opts options, args []string) error {
+	if len(args) != 1 {
+		err := fmt.Errorf("expected the manifest to import, one of %s", strings.Join(manifests.Names(), ", "))
+		return &usageError{cmd: lookupCommand("import"), err: err}
+	}
+
+	configPath := filepath.Join(pwd, gopack.ConfigFile)
+	if _, err := os.Stat(configPath); err == nil && !opts.bool("force") {
+		return fmt.Errorf("%s already exists, use --force to overwrite it", gopack.ConfigFile)
+	}
+
+	m, err := manifests.Parse(args[0])
+	if err != nil {
+		return err
+	}
+	for _, problem := range m.Problems {
+		warnf("%s\n", problem)
+	}
+	if err := ioutil.WriteFile(configPath, m.Config(), 0644); err != nil {
+		return err
+	}
+	infof("imported %d dependencies from %s to %s\n", len(m.Entries), m.File, gopack.ConfigFile)
+	return nil
+}
+
+// Prune the vendor tree down to the packages the project imports.
+func pruneVendor(p *stats.ProjectStats, opts options) error {
+	imports := make([]string, 0, len(p.ImportStatsByPath))
+	for importPath := range p.ImportStatsByPath {
+		imports = append(imports, importPath)
+	}
+
+	result, err := prune.Prune(pwd, imports, prune.Options{
+		Tests:  opts.bool("drop-tests"),
+		Assets: opts.bool("drop-assets"),
+	})
+	if err != nil {
+		return err
+	}
+	infof("pruned %d files, %s saved\n", len(result.Removed), formatBytes(result.BytesSaved))
+	return nil
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func loadDependencies(p *project, tests bool) (*deps.Dependencies, error) {
+	dependencies, err := loadConfiguration(p)
+	if err != nil {
+		return nil, err
+	}
+
+	if !dependencies.NeedsFetch() {
+		debugf("%s is unchanged since the last run, nothing to fetch\n", gopack.ConfigFile)
+		return dependencies, nil
+	}
+
+	announceGopack()
+	// the project is validated against its own declarations only
+	own := dependencies
+	if p.workspace != nil {
+		own = dependencies.DeclaredIn(p.config.Path)
+	}
+	err = reportErrors(own.Validate(p.stats))
+	if err != nil {
+		return nil, err
+	}
+	// prepare dependencies, test ones only when running tests
+	err = loadTransitiveDependencies(dependencies, tests)
+	if err != nil {
+		return nil, err
+	}
+	err = reportErrors(own.ValidatePackages(p.stats))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range p.configs() {
+		if err := c.WriteChecksum(root); err != nil {
+			return nil, err
+		}
+	}
+	return dependencies, nil
+}
+
+func loadConfiguration(p *project) (*deps.Dependencies, error) {
+	importGraph := deps.NewGraph()
+	err := linkProjects(p, importGraph)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.workspace != nil {
+		return deps.LoadWorkspace(p.configs(), root, importGraph)
+	}
+	return deps.Load(p.config, root, importGraph)
+}
+
+// Link the local projects into the vendor tree: the project's
+// own repository, or every project of its workspace.
+func linkProjects(p *project, importGraph *deps.Graph) error {
+	if p.workspace != nil {
+		return deps.InitWorkspace(p.workspace.Configs, root, importGraph)
+	}
+	return deps.InitRepo(p.config, root, importGraph)
+}
+
+// Every dependency of the project, in place of the ones the projects
+// linked in the vendor tree provide, without fetching anything.
+func resolveDependencies(p *project) ([]*deps.Dep, error) {
+	graph := deps.NewGraph()
+	if err := linkProjects(p, graph); err != nil {
+		return nil, err
+	}
+	return deps.Resolve(p.config, root, graph)
+}
+
+// Run the go command with the arguments as they are.
+func runGo(p *project, opts options, args []string) error {
+	if args[0] == "version" {
+		fmt.Printf("gopack version %s\n", gopack.Version)
+	}
+
+	verbosef("GOPATH=%s go %s\n", lookupEnv(env, "GOPATH"), strings.Join(args, " "))
+	cmd := exec.Command("go", args...)
+	cmd.Env = env
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err := cmd.Run()
+
+	e := event{Event: commandEvent, Command: "go " + strings.Join(args, " ")}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	emit(e)
+	return err
+}
+
+// Fetch the dependencies and check them out, then the ones they declare
+// themselves, level by level. Every import is handled once, where it's
+// declared closest to the project, like deps.Resolve does.
+func loadTransitiveDependencies(dependencies *deps.Dependencies, tests bool) error {
+	handled := make(map[string]bool)
+	level := []*deps.Dependencies{dependencies}
+	// the tests of dependencies never run
+	for ; len(level) > 0; tests = false {
+		next := []*deps.Dependencies{}
+		for _, declared := range level {
+			err := declared.VisitDeps(
+				func(dep *deps.Dep) error {
+					if handled[dep.Import] {
+						debugf("skipping %s %s, it's declared closer to the project\n", dep.Import, dep.CheckoutSpec)
+						return nil
+					}
+					if dep.TestOnly() && !tests {
+						debugf("skipping %s, only tests need it\n", dep.Import)
+						return nil
+					}
+					handled[dep.Import] = true
+					if dep.Local != "" {
+						debugf("using %s from %s\n", dep.Import, dep.Local)
+						return nil
+					}
+
+					transitive, err := loadDependency(dep, declared.ImportGraph)
+					if err != nil || transitive == nil {
+						return err
+					}
+					next = append(next, transitive)
+					return nil
+				})
+			if err != nil {
+				return err
+			}
+		}
+		level = next
+	}
+	return nil
+}
+
+// Fetch the dependency and check it out, returning the dependencies it
+// declares if any of them needs to be fetched.
+func loadDependency(dep *deps.Dep, importGraph *deps.Graph) (*deps.Dependencies, error) {
+	progress("updating %s\n", dep.Import)
+	err := dep.GoGetUpdate(env)
+	if dep.Fetching() {
+		depEvent(fetchEvent, dep.Import, "", "", err)
+	}
+	if err != nil {
+		return nil, deps.FetchError(dep, err)
+	}
+
+	if dep.CheckoutType() != "" {
+		progress("pointing %s at %s %s\n", dep.Import, dep.CheckoutType(), dep.CheckoutSpec)
+		err := dep.SwitchToBranchOrTag()
+		depEvent(checkoutEvent, dep.Import, dep.CheckoutType(), dep.CheckoutSpec, err)
+		if err != nil {
+			warnf("%s\n", err)
+		}
+	}
+	transitive, err := dep.LoadTransitiveDeps(importGraph)
+	if err != nil || transitive == nil {
+		return nil, err
+	}
+	for _, w := range transitive.Warnings {
+		warnf("%s\n", w)
+	}
+	if !transitive.NeedsFetch() {
+		return nil, nil
+	}
+	debugf("%s declares %s\n", dep.Import, strings.Join(transitive.Imports, ", "))
+	return transitive, reportErrors(transitive.Conflicts)
+}
+
+// Set the working directory.
+// It's the current directory by default, the one --config changes to.
+// It can be overriden setting the environment variable GOPACK_APP_CONFIG.
+func setPwd() error {
+	var dir string
+	var err error
+
+	if configDir == "" {
+		dir = os.Getenv("GOPACK_APP_CONFIG")
+	}
+	if dir == "" {
+		dir, err = os.Getwd()
+		if err != nil {
+			return err
+		}
+	}
+
+	pwd = dir
+	root = dir
+	return nil
+}
+
+// Print warnings and return the rest of the errors, if any.
+// Warnings are returned too in strict mode.
+func reportErrors(errors []*gopack.ProjectError) error {
+	failures := gopack.ProjectErrors{}
+	for _, e := range errors {
+		errorEvent(e)
+		if e.Warning && !strict {
+			warnf("%s", e.String())
+		} else {
+			failures = append(failures, e)
+		}
+	}
+
+	if len(failures) > 0 {
+		return failures
+	}
+	return nil
+}
+
+func printError(err error) {
+	// the go command already reported its own failure
+	if _, ok := err.(*exec.ExitError); ok {
+		return
+	}
+
+	fprintcolor(os.Stderr, Red, "%s", err)
+	fmt.Fprintln(os.Stderr)
+}
+
+func announceGopack() {
+	logf(levelNormal, 104, "/// g o p a c k ///")
+	if level >= levelNormal {
+		fmt.Fprintln(logOutput)
+	}
+}
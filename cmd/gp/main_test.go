@@ -0,0 +1,349 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/d2fn/gopack"
+	"github.com/d2fn/gopack/config"
+	"github.com/d2fn/gopack/deps"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func check(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
+
+func setupTestPwd() {
+	dir, _ := ioutil.TempDir("", "gopack-config-")
+	os.Setenv("GOPACK_APP_CONFIG", dir)
+	setPwd()
+}
+
+func TestSetPwdDefault(t *testing.T) {
+	os.Setenv("GOPACK_APP_CONFIG", "")
+	setPwd()
+	dir, _ := os.Getwd()
+	if pwd != dir {
+		t.Errorf("Expected pwd to be %s but it was %s.\n", dir, pwd)
+	}
+}
+
+func TestSetPwdAppConfig(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "gopack-test-")
+	os.Setenv("GOPACK_APP_CONFIG", dir)
+	setPwd()
+	if pwd != dir {
+		t.Errorf("Expected pwd to be %s but it was %s.\n", dir, pwd)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	codes := map[int]error{
+		ExitConfig:     &gopack.ProjectError{Kind: gopack.MissingImport},
+		ExitValidation: gopack.ProjectErrors{{Kind: gopack.UnusedDep}},
+		ExitFetch:      &gopack.ProjectError{Kind: gopack.FetchFailed},
+		ExitFailure:    os.ErrNotExist,
+	}
+
+	for expected, err := range codes {
+		if code := ExitCode(err); code != expected {
+			t.Errorf("Expected exit code for %v to be %d but it was %d\n", err, expected, code)
+		}
+	}
+}
+
+func TestGoEnv(t *testing.T) {
+	setupTestPwd()
+	vendor := path.Join(pwd, gopack.VendorDir)
+	environ := []string{"HOME=/home/gopher", "GOPATH=/home/gopher/go", "CGO_ENABLED=1", "GO111MODULE=on"}
+
+	composed := goEnv(&config.Config{}, environ)
+	if lookupEnv(composed, "GOPATH") != vendor || lookupEnv(composed, "HOME") != "/home/gopher" {
+		t.Errorf("Expected GOPATH to be replaced by the vendor tree but the environment was %v\n", composed)
+	}
+	if lookupEnv(composed, "GO111MODULE") != "off" {
+		t.Errorf("Expected modules to be turned off but the environment was %v\n", composed)
+	}
+	if lookupEnv(environ, "GOPATH") != "/home/gopher/go" || lookupEnv(environ, "GO111MODULE") != "on" {
+		t.Error("Expected the user's environment to be left untouched")
+	}
+
+	c := &config.Config{AppendGopath: true, Env: map[string]string{"CGO_ENABLED": "0", "GOFLAGS": "-mod=vendor"}}
+	composed = goEnv(c, environ)
+	expected := map[string]string{
+		"GOPATH":      vendor + string(os.PathListSeparator) + "/home/gopher/go",
+		"CGO_ENABLED": "0",
+		"GOFLAGS":     "-mod=vendor",
+		"GO111MODULE": "off",
+	}
+	for k, v := range expected {
+		if value := lookupEnv(composed, k); value != v {
+			t.Errorf("Expected %s to be %s but it was %s\n", k, v, value)
+		}
+	}
+	if len(composed) != 5 {
+		t.Errorf("Expected every variable to be set once but the environment was %v\n", composed)
+	}
+
+	c = &config.Config{Env: map[string]string{"GO111MODULE": "auto"}}
+	if value := lookupEnv(goEnv(c, environ), "GO111MODULE"); value != "auto" {
+		t.Errorf("Expected the env table to override GO111MODULE but it was %s\n", value)
+	}
+}
+
+func TestTransitiveDependencies(t *testing.T) {
+	setupTestPwd()
+
+	fixture := `
+[deps.testgopack]
+  import = "github.com/calavera/testGoPack"
+  branch = "master"
+`
+	err := ioutil.WriteFile(path.Join(pwd, gopack.ConfigFile), []byte(fixture), 0644)
+	check(err)
+
+	c, err := config.NewConfig(pwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env = goEnv(c, os.Environ())
+	dependencies, err := deps.Load(c, pwd, deps.NewGraph())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := loadTransitiveDependencies(dependencies, false); err != nil {
+		t.Fatal(err)
+	}
+
+	dep := path.Join(pwd, gopack.VendorDir, "src", "github.com", "calavera", "testGoPack")
+	if _, err := os.Stat(dep); os.IsNotExist(err) {
+		t.Errorf("Expected dependency github.com/calavera/testGoPack to be in vendor %s\n", pwd)
+	}
+
+	dep = path.Join(pwd, gopack.VendorDir, "src", "github.com", "d2fn", "gopack")
+	if _, err := os.Stat(dep); os.IsNotExist(err) {
+		t.Errorf("Expected dependency github.com/d2fn/gopack to be in vendor %s\n", pwd)
+	}
+}
+
+func TestParseFlags(t *testing.T) {
+	defer func() { strict, level, configDir = false, levelNormal, "" }()
+
+	args, passthrough, err := parseFlags([]string{"--strict", "--config=../app", "-quiet", "stats", "--packages"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strict || level != levelQuiet || configDir != "../app" {
+		t.Errorf("Expected the global flags to be set, strict %v, level %d, config %s\n", strict, level, configDir)
+	}
+	if passthrough || len(args) != 2 || args[0] != "stats" || args[1] != "--packages" {
+		t.Errorf("Expected to leave the stats command but it was %v\n", args)
+	}
+
+	args, passthrough, err = parseFlags([]string{"--", "vendor", "-v"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !passthrough || len(args) != 2 || args[0] != "vendor" {
+		t.Errorf("Expected -- to pass vendor to the go command but it was %v\n", args)
+	}
+
+	if _, _, err := parseFlags([]string{"--quiet", "--debug", "build"}); err == nil {
+		t.Error("Expected --quiet and --debug to be exclusive")
+	}
+	if _, _, err := parseFlags([]string{"--colour", "build"}); err == nil {
+		t.Error("Expected an unknown flag to fail")
+	}
+}
+
+func TestCommandFlags(t *testing.T) {
+	cmd := lookupCommand("stats")
+	opts, args, err := cmd.parse([]string{"--history", "--samples=5", "extra"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !opts.bool("history") || opts["samples"] != "5" || opts.bool("csv") {
+		t.Errorf("Expected --history and --samples to be set but the flags were %v\n", opts)
+	}
+	if len(args) != 1 || args[0] != "extra" {
+		t.Errorf("Expected the arguments to be left but they were %v\n", args)
+	}
+
+	if _, _, err := cmd.parse([]string{"--force"}); err == nil {
+		t.Error("Expected the flags of other commands to fail")
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	for shell, complete := range completions {
+		var buf bytes.Buffer
+		complete(&buf)
+		script := buf.String()
+
+		for _, cmd := range commands {
+			if !strings.Contains(script, cmd.name) {
+				t.Errorf("Expected the %s completion to offer %s\n", shell, cmd.name)
+			}
+			for _, f := range cmd.flags {
+				if !strings.Contains(script, "--"+f.name) {
+					t.Errorf("Expected the %s completion to offer --%s for %s\n", shell, f.name, cmd.name)
+				}
+			}
+		}
+	}
+}
+
+func TestCommandRequirements(t *testing.T) {
+	expected := map[string]requirement{
+		"version": environment,
+		"fmt":     environment,
+		"build":   resolved,
+		"test":    resolved,
+		"install": resolved,
+	}
+	for name, needs := range expected {
+		if cmd := goCommand(name); cmd.needs != needs {
+			t.Errorf("Expected go %s to need %d but it needs %d\n", name, needs, cmd.needs)
+		}
+	}
+
+	for _, cmd := range commands {
+		if cmd.needs == resolved {
+			t.Errorf("Expected gp %s to run without fetching the dependencies\n", cmd.name)
+		}
+	}
+
+	for _, c := range []struct {
+		name  string
+		opts  options
+		needs requirement
+	}{
+		{"vendor", options{}, configured},
+		{"vendor", options{"verify": "true"}, configured},
+		{"vendor", options{"prune": "true"}, analyzed},
+		{"stats", options{}, analyzed},
+		{"stats", options{"history": "true"}, configured},
+	} {
+		if needs := lookupCommand(c.name).requires(c.opts); needs != c.needs {
+			t.Errorf("Expected gp %s %v to need %d but it needs %d\n", c.name, c.opts, c.needs, needs)
+		}
+	}
+}
+
+func TestLoadProjectWithoutConfig(t *testing.T) {
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	setupTestPwd()
+	check(os.Chdir(pwd))
+
+	p, err := loadProject(goCommand("version"), options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.config == nil || p.stats != nil || lookupEnv(env, "GOPATH") != path.Join(pwd, gopack.VendorDir) {
+		t.Errorf("Expected go version to run with the vendor tree only, got %+v\n", p)
+	}
+
+	if _, err := loadProject(goCommand("build"), options{}); err == nil {
+		t.Error("Expected go build to need gopack.config")
+	}
+
+	check(ioutil.WriteFile(path.Join(pwd, gopack.ConfigFile), []byte("[deps.mux]\n  tga = \"v1.0.0\"\n"), 0644))
+	if _, err := loadProject(goCommand("version"), options{}); err != nil {
+		t.Errorf("Expected go version to run whatever the state of gopack.config but it failed with %s\n", err)
+	}
+}
+
+func TestLogLevels(t *testing.T) {
+	defer func() { level, logOutput = levelNormal, os.Stderr }()
+	var buf bytes.Buffer
+	logOutput = &buf
+
+	level = levelQuiet
+	progress("updating %s\n", "github.com/gorilla/mux")
+	warnf("%s\n", "github.com/gorilla/mux points at branch master")
+	if buf.String() != "warning: github.com/gorilla/mux points at branch master\n" {
+		t.Errorf("Expected only warnings when quiet but it printed %q\n", buf.String())
+	}
+
+	buf.Reset()
+	level = levelVerbose
+	verbosef("go build\n")
+	debugf("skipping launchpad.net/gocheck\n")
+	if buf.String() != "go build\n" {
+		t.Errorf("Expected verbose messages but no debug ones but it printed %q\n", buf.String())
+	}
+}
+
+func TestEvents(t *testing.T) {
+	defer func() { events = nil }()
+	f, err := ioutil.TempFile("", "gopack-events-")
+	check(err)
+	f.Close()
+	check(openEvents(f.Name()))
+
+	depEvent(checkoutEvent, "github.com/gorilla/mux", "tag", "v1.6.2", nil)
+	reportErrors([]*gopack.ProjectError{{Kind: gopack.FloatingDep, Message: "floating\n", Warning: true}})
+
+	dat, err := ioutil.ReadFile(f.Name())
+	check(err)
+	lines := strings.Split(strings.TrimSpace(string(dat)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 events but found %q\n", lines)
+	}
+
+	e := event{}
+	check(json.Unmarshal([]byte(lines[0]), &e))
+	if e.Event != checkoutEvent || e.Import != "github.com/gorilla/mux" || e.Spec != "v1.6.2" || e.Time.IsZero() {
+		t.Errorf("Expected a checkout event but it was %s\n", lines[0])
+	}
+	check(json.Unmarshal([]byte(lines[1]), &e))
+	if e.Event != validationEvent || e.Kind != gopack.FloatingDep || !e.Warning {
+		t.Errorf("Expected a validation event but it was %s\n", lines[1])
+	}
+}
+
+func TestColors(t *testing.T) {
+	defer func() { colorMode = colorAuto }()
+	defer os.Setenv("TERM", os.Getenv("TERM"))
+	defer os.Setenv("NO_COLOR", os.Getenv("NO_COLOR"))
+	os.Setenv("TERM", "xterm")
+	os.Setenv("NO_COLOR", "")
+
+	if !wantColor(true) || wantColor(false) {
+		t.Error("Expected colors only on terminals by default")
+	}
+
+	os.Setenv("NO_COLOR", "1")
+	if wantColor(true) {
+		t.Error("Expected NO_COLOR to turn colors off")
+	}
+	colorMode = colorAlways
+	if !wantColor(false) {
+		t.Error("Expected --color=always to win over NO_COLOR")
+	}
+	os.Setenv("NO_COLOR", "")
+
+	colorMode = colorAuto
+	os.Setenv("TERM", "dumb")
+	if wantColor(true) {
+		t.Error("Expected dumb terminals to get no colors")
+	}
+
+	var buf bytes.Buffer
+	colorMode = colorAlways
+	fprintcolor(&buf, Red, "failed")
+	if buf.String() != "\033[31mfailed"+EndColor {
+		t.Errorf("Expected a red message but it was %q\n", buf.String())
+	}
+
+	if _, _, err := parseFlags([]string{"--color=sometimes", "build"}); err == nil {
+		t.Error("Expected an invalid --color to fail")
+	}
+}
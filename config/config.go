@@ -0,0 +1,203 @@
+// Package config reads gopack.config files.
+package config
+
+import (
+	"bytes"
+	"crypto/md5"
+	"github.com/d2fn/gopack"
+	"github.com/pelletier/go-toml"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type Config struct {
+	Checksum []byte
+	// Path to the configuration file.
+	Path string
+	// Name of your repository "github.com/d2fn/gopack" for instance.
+	Repository string
+	// Dependencies tree
+	DepsTree *toml.TomlTree
+	// Dependencies allowed to track a branch, by key or import path.
+	AllowFloating []string
+	// GOOS/GOARCH pairs the project is built for.
+	Platforms []string
+	// Build tags set when building the project.
+	Tags []string
+	// Patterns of the files and directories left out of the analysis.
+	Ignore []string
+	// Variables set for the go command, on top of the user's environment.
+	Env map[string]string
+	// Whether the user's GOPATH follows the vendor tree in the GOPATH
+	// of the go command, instead of being replaced by it.
+	AppendGopath bool
+	// Where the checksum of the configuration is kept, relative to the
+	// root of the vendor tree. gopack.GopackChecksum when empty.
+	ChecksumFile string
+	// Position of each table and key in the configuration file.
+	positions map[string]token.Position
+}
+
+func NewConfig(dir string) (*Config, error) {
+	config := &Config{Path: filepath.Join(dir, gopack.ConfigFile)}
+
+	dat, err := ioutil.ReadFile(config.Path)
+	if err != nil {
+		return nil, ConfigError(config.Path, err)
+	}
+
+	t, err := toml.Load(string(dat))
+	if err != nil {
+		return nil, ConfigError(config.Path, err)
+	}
+	config.positions = keyPositions(config.Path, dat)
+
+	if errors := config.validateSchema(t); len(errors) > 0 {
+		return nil, errors
+	}
+
+	if deps := t.Get("deps"); deps != nil {
+		config.DepsTree = deps.(*toml.TomlTree)
+	}
+
+	if repo := t.Get("repo"); repo != nil {
+		config.Repository = repo.(string)
+	}
+
+	config.AllowFloating = Strings(t, "allow_floating")
+	config.Platforms = Strings(t, "platforms")
+	config.Tags = Strings(t, "tags")
+	config.Ignore = Strings(t, "ignore")
+	config.AppendGopath, _ = t.Get("append_gopath").(bool)
+
+	if env, ok := t.Get("env").(*toml.TomlTree); ok {
+		config.Env = make(map[string]string)
+		for _, k := range env.Keys() {
+			config.Env[k] = env.Get(k).(string)
+		}
+	}
+
+	return config, nil
+}
+
+// Strings in the array at key, nil if the key is not set.
+func Strings(t *toml.TomlTree, key string) []string {
+	var strings []string
+	if array, ok := t.Get(key).([]interface{}); ok {
+		for _, v := range array {
+			strings = append(strings, v.(string))
+		}
+	}
+	return strings
+}
+
+// Find the line and column where every table and key is declared,
+// the toml parser doesn't keep track of positions.
+// Keys are indexed by their full path, like deps.mux.import.
+func keyPositions(path string, dat []byte) map[string]token.Position {
+	positions := make(map[string]token.Position)
+	group := ""
+	for i, line := range strings.Split(string(dat), "\n") {
+		trimmed := strings.TrimSpace(line)
+		column := strings.Index(line, trimmed) + 1
+		pos := token.Position{Filename: path, Line: i + 1, Column: column}
+
+		if strings.HasPrefix(trimmed, "[") {
+			end := strings.Index(trimmed, "]")
+			if end < 0 {
+				continue
+			}
+			group = strings.TrimSpace(trimmed[1:end])
+			positions[group] = pos
+		} else if eq := strings.Index(trimmed, "="); eq > 0 && !strings.HasPrefix(trimmed, "#") {
+			key := strings.TrimSpace(trimmed[:eq])
+			if group != "" {
+				key = group + "." + key
+			}
+			if _, found := positions[key]; !found {
+				positions[key] = pos
+			}
+		}
+	}
+	return positions
+}
+
+// Keys of the [deps.x] tables, in the order they are declared.
+func (c *Config) DepKeys() []string {
+	if c.DepsTree == nil {
+		return nil
+	}
+	return c.sortedKeys(c.DepsTree, "deps.")
+}
+
+// Import paths of the dependencies, in the order they are declared.
+func (c *Config) DepImports() []string {
+	imports := []string{}
+	for _, k := range c.DepKeys() {
+		if importPath, ok := c.DepsTree.Get(k + ".import").(string); ok {
+			imports = append(imports, importPath)
+		}
+	}
+	return imports
+}
+
+// Position of the [deps.key] table in the configuration file.
+func (c *Config) Position(key string) token.Position {
+	return c.positions["deps."+key]
+}
+
+func (c *Config) AllowsFloating(key, importPath string) bool {
+	for _, a := range c.AllowFloating {
+		if a == key || a == importPath {
+			return true
+		}
+	}
+	return false
+}
+
+// Whether the configuration changed since the checksum
+// was written in the project at root.
+func (c *Config) ModifiedChecksum(root string) (bool, error) {
+	sum, err := c.checksum()
+	if err != nil {
+		return false, err
+	}
+
+	dat, err := ioutil.ReadFile(c.checksumPath(root))
+	return (err != nil && os.IsNotExist(err)) || !bytes.Equal(dat, sum), nil
+}
+
+func (c *Config) WriteChecksum(root string) error {
+	sum, err := c.checksum()
+	if err != nil {
+		return err
+	}
+
+	path := c.checksumPath(root)
+	os.MkdirAll(filepath.Dir(path), 0755)
+	return ioutil.WriteFile(path, sum, 0644)
+}
+
+func (c *Config) checksumPath(root string) string {
+	if c.ChecksumFile != "" {
+		return filepath.Join(root, c.ChecksumFile)
+	}
+	return filepath.Join(root, gopack.GopackChecksum)
+}
+
+func (c *Config) checksum() ([]byte, error) {
+	if c.Checksum == nil {
+		dat, err := ioutil.ReadFile(c.Path)
+		if err != nil {
+			return nil, ConfigError(c.Path, err)
+		}
+
+		h := md5.New()
+		h.Write(dat)
+		c.Checksum = h.Sum(nil)
+	}
+	return c.Checksum, nil
+}
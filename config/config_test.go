@@ -0,0 +1,177 @@
+package config
+
+import (
+	"github.com/d2fn/gopack"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+)
+
+func check(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
+
+func createFixtureConfig(dir string, config string) {
+	err := ioutil.WriteFile(path.Join(dir, gopack.ConfigFile), []byte(config), 0644)
+	check(err)
+}
+
+func setupTestConfig(fixture string) (*Config, string) {
+	dir, err := ioutil.TempDir("", "gopack-config-")
+	check(err)
+
+	createFixtureConfig(dir, fixture)
+	config, err := NewConfig(dir)
+	check(err)
+	return config, dir
+}
+
+func TestNewConfig(t *testing.T) {
+	config, _ := setupTestConfig(`
+repo = "github.com/d2fn/gopack"
+
+[deps.testgopack]
+  import = "github.com/calavera/testGoPack"
+  branch = "master"
+`)
+
+	if config.Repository == "" {
+		t.Error("Expected repository to not be empty.")
+	}
+
+	if config.DepsTree == nil {
+		t.Error("Expected dependency tree to not be empty.")
+	}
+}
+
+func TestWriteChecksum(t *testing.T) {
+	config, dir := setupTestConfig(`
+[deps.testgopack]
+  import = "github.com/calavera/testGoPack"
+  branch = "master"
+`)
+
+	if err := config.WriteChecksum(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	path := path.Join(dir, gopack.GopackChecksum)
+	_, err := ioutil.ReadFile(path)
+	if err != nil && os.IsNotExist(err) {
+		t.Errorf("Expected checksum file %s to exist", path)
+	}
+}
+
+func TestModifiedChecksum(t *testing.T) {
+	config, dir := setupTestConfig(`
+[deps.testgopack]
+  import = "github.com/calavera/testGoPack"
+  branch = "master"
+`)
+
+	modified, err := config.ModifiedChecksum(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !modified {
+		t.Error("Expected checksum to be modified when there is no checksum file")
+	}
+
+	check(config.WriteChecksum(dir))
+
+	modified, err = config.ModifiedChecksum(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if modified {
+		t.Error("Expected checksum to not be modified after writing it")
+	}
+}
+
+func TestDependencyPositions(t *testing.T) {
+	config, _ := setupTestConfig(`
+[deps.testgopack]
+  import = "github.com/calavera/testGoPack"
+  branch = "master"
+
+  [deps.foo]
+  import = "github.com/calavera/foo"
+  tag = "v1.0.0"
+`)
+
+	for key, expected := range map[string][]int{"testgopack": {2, 1}, "foo": {6, 3}} {
+		pos := config.Position(key)
+		if pos.Line != expected[0] || pos.Column != expected[1] {
+			t.Errorf("Expected %s to be declared in %d:%d but it was %d:%d\n", key, expected[0], expected[1], pos.Line, pos.Column)
+		}
+		if pos.Filename != config.Path {
+			t.Errorf("Expected %s to be declared in %s but it was %s\n", key, config.Path, pos.Filename)
+		}
+	}
+}
+
+func TestAllowsFloating(t *testing.T) {
+	config, _ := setupTestConfig(`
+allow_floating = ["testgopack", "github.com/calavera/bar"]
+`)
+
+	if !config.AllowsFloating("testgopack", "github.com/calavera/testGoPack") {
+		t.Error("Expected to allow floating dependencies by key")
+	}
+
+	if !config.AllowsFloating("bar", "github.com/calavera/bar") {
+		t.Error("Expected to allow floating dependencies by import path")
+	}
+
+	if config.AllowsFloating("foo", "github.com/calavera/foo") {
+		t.Error("Expected to not allow floating dependencies out of the list")
+	}
+}
+
+func TestMissingConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gopack-config-")
+	check(err)
+
+	_, err = NewConfig(dir)
+	e, ok := err.(*gopack.ProjectError)
+	if !ok || e.Kind != gopack.InvalidConfig {
+		t.Fatalf("Expected an invalid config error but it was %v\n", err)
+	}
+}
+
+func TestDepImports(t *testing.T) {
+	config, _ := setupTestConfig(`
+[deps.testgopack]
+  import = "github.com/calavera/testGoPack"
+  branch = "master"
+
+[deps.foo]
+  import = "github.com/calavera/foo"
+  tag = "v1.0.0"
+`)
+
+	imports := config.DepImports()
+	if len(imports) != 2 || imports[0] != "github.com/calavera/testGoPack" || imports[1] != "github.com/calavera/foo" {
+		t.Errorf("Expected the import paths in declaration order but they were %v\n", imports)
+	}
+}
+
+func TestEnv(t *testing.T) {
+	config, _ := setupTestConfig(`
+append_gopath = true
+
+[env]
+  CGO_ENABLED = "0"
+  GOFLAGS = "-mod=vendor"
+`)
+
+	if !config.AppendGopath {
+		t.Error("Expected to append the user's GOPATH")
+	}
+	if len(config.Env) != 2 || config.Env["CGO_ENABLED"] != "0" || config.Env["GOFLAGS"] != "-mod=vendor" {
+		t.Errorf("Expected the variables of the env table but found %v\n", config.Env)
+	}
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"fmt"
+	"github.com/d2fn/gopack"
+	"go/token"
+	"strings"
+)
+
+func ConfigError(path string, err error) *gopack.ProjectError {
+	return &gopack.ProjectError{
+		Kind:    gopack.InvalidConfig,
+		Message: fmt.Sprintf("%s: %s\n", path, err),
+	}
+}
+
+func MissingImportError(key string, pos token.Position) *gopack.ProjectError {
+	return &gopack.ProjectError{
+		Kind:     gopack.MissingImport,
+		Message:  fmt.Sprintf("deps.%s doesn't specify the import path\n", key),
+		Position: pos,
+	}
+}
+
+func UnknownKeyError(key, suggestion string, pos token.Position) *gopack.ProjectError {
+	msg := fmt.Sprintf("unknown key %s", key)
+	if suggestion != "" {
+		msg = fmt.Sprintf("%s, did you mean %s?", msg, suggestion)
+	}
+
+	return &gopack.ProjectError{
+		Kind:     gopack.UnknownKey,
+		Message:  msg + "\n",
+		Position: pos,
+	}
+}
+
+func InvalidTypeError(key, expected string, value interface{}, pos token.Position) *gopack.ProjectError {
+	return &gopack.ProjectError{
+		Kind:     gopack.InvalidType,
+		Message:  fmt.Sprintf("%s must be %s %s, found %s\n", key, article(expected), expected, typeName(value)),
+		Position: pos,
+	}
+}
+
+func InvalidValueError(key string, err error, pos token.Position) *gopack.ProjectError {
+	return &gopack.ProjectError{
+		Kind:     gopack.InvalidValue,
+		Message:  fmt.Sprintf("invalid %s: %s\n", key, err),
+		Position: pos,
+	}
+}
+
+func DuplicateImportError(key, other, importPath string, pos token.Position) *gopack.ProjectError {
+	return &gopack.ProjectError{
+		Kind:     gopack.DuplicateImport,
+		Message:  fmt.Sprintf("%s imports %s, already imported by %s\n", key, importPath, other),
+		Position: pos,
+	}
+}
+
+func article(noun string) string {
+	if strings.IndexAny(noun[:1], "aeiou") == 0 {
+		return "an"
+	}
+	return "a"
+}
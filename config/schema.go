@@ -0,0 +1,270 @@
+package config
+
+import (
+	"fmt"
+	"github.com/d2fn/gopack"
+	"github.com/d2fn/gopack/stats"
+	"github.com/pelletier/go-toml"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	stringType      = "string"
+	stringArrayType = "array of strings"
+	tableType       = "table"
+	boolType        = "boolean"
+)
+
+// Keys accepted at the top level of gopack.config and their types.
+var rootSchema = map[string]string{
+	"repo":           stringType,
+	"deps":           tableType,
+	"allow_floating": stringArrayType,
+	"platforms":      stringArrayType,
+	"tags":           stringArrayType,
+	"ignore":         stringArrayType,
+	// variables set for the go command
+	"env":           tableType,
+	"append_gopath": boolType,
+}
+
+// Keys accepted in every [deps.x] table and their types.
+var depSchema = map[string]string{
+	"import": stringType,
+	"branch": stringType,
+	"commit": stringType,
+	"tag":    stringType,
+	// platforms the dependency is needed for
+	"platforms": stringArrayType,
+	// "test" for dependencies only tests need
+	"scope": stringType,
+}
+
+// Values accepted by the scope of a dependency.
+var depScopes = []string{"test"}
+
+// Check the configuration tree against the schema, reporting
+// unknown keys, values with the wrong type, dependencies
+// without an import path and imports declared more than once.
+func (c *Config) validateSchema(t *toml.TomlTree) gopack.ProjectErrors {
+	errors := c.validateKeys(t, "", rootSchema)
+	errors = append(errors, c.validatePlatforms(t, "")...)
+	errors = append(errors, c.validateIgnore(t)...)
+	errors = append(errors, c.validateEnv(t)...)
+
+	deps, ok := t.Get("deps").(*toml.TomlTree)
+	if !ok {
+		return sortErrors(errors)
+	}
+
+	imports := make(map[string]string)
+	for _, k := range c.sortedKeys(deps, "deps.") {
+		group := "deps." + k
+		depTree, ok := deps.Get(k).(*toml.TomlTree)
+		if !ok {
+			errors = append(errors, InvalidTypeError(group, tableType, deps.Get(k), c.positions[group]))
+			continue
+		}
+
+		errors = append(errors, c.validateKeys(depTree, group+".", depSchema)...)
+		errors = append(errors, c.validatePlatforms(depTree, group+".")...)
+		errors = append(errors, c.validateScope(depTree, group+".")...)
+
+		importPath, ok := depTree.Get("import").(string)
+		if depTree.Get("import") == nil {
+			errors = append(errors, MissingImportError(k, c.positions[group]))
+		} else if other, found := imports[importPath]; ok && found {
+			errors = append(errors, DuplicateImportError(group, other, importPath, c.positions[group]))
+		} else if ok {
+			imports[importPath] = group
+		}
+	}
+
+	return sortErrors(errors)
+}
+
+func (c *Config) validateKeys(t *toml.TomlTree, prefix string, schema map[string]string) gopack.ProjectErrors {
+	errors := gopack.ProjectErrors{}
+	for _, k := range c.sortedKeys(t, prefix) {
+		expected, known := schema[k]
+		if !known {
+			errors = append(errors, UnknownKeyError(prefix+k, suggest(k, schema), c.positions[prefix+k]))
+		} else if !hasType(t.Get(k), expected) {
+			errors = append(errors, InvalidTypeError(prefix+k, expected, t.Get(k), c.positions[prefix+k]))
+		}
+	}
+	return errors
+}
+
+// Platforms must be GOOS/GOARCH pairs.
+func (c *Config) validatePlatforms(t *toml.TomlTree, prefix string) gopack.ProjectErrors {
+	errors := gopack.ProjectErrors{}
+	if !hasType(t.Get("platforms"), stringArrayType) {
+		return errors
+	}
+
+	for _, platform := range Strings(t, "platforms") {
+		if _, _, err := stats.ParsePlatform(platform); err != nil {
+			errors = append(errors, InvalidValueError(prefix+"platforms", err, c.positions[prefix+"platforms"]))
+		}
+	}
+	return errors
+}
+
+// Ignore patterns must be valid globs.
+func (c *Config) validateIgnore(t *toml.TomlTree) gopack.ProjectErrors {
+	errors := gopack.ProjectErrors{}
+	if !hasType(t.Get("ignore"), stringArrayType) {
+		return errors
+	}
+
+	for _, pattern := range Strings(t, "ignore") {
+		if _, err := path.Match(pattern, ""); err != nil {
+			err = fmt.Errorf("%s is not a valid pattern", pattern)
+			errors = append(errors, InvalidValueError("ignore", err, c.positions["ignore"]))
+		}
+	}
+	return errors
+}
+
+// Every variable of the environment must be a string.
+func (c *Config) validateEnv(t *toml.TomlTree) gopack.ProjectErrors {
+	errors := gopack.ProjectErrors{}
+	env, ok := t.Get("env").(*toml.TomlTree)
+	if !ok {
+		return errors
+	}
+
+	for _, k := range c.sortedKeys(env, "env.") {
+		if !hasType(env.Get(k), stringType) {
+			errors = append(errors, InvalidTypeError("env."+k, stringType, env.Get(k), c.positions["env."+k]))
+		}
+	}
+	return errors
+}
+
+func (c *Config) validateScope(t *toml.TomlTree, prefix string) gopack.ProjectErrors {
+	errors := gopack.ProjectErrors{}
+	scope, ok := t.Get("scope").(string)
+	if !ok {
+		return errors
+	}
+
+	for _, s := range depScopes {
+		if scope == s {
+			return errors
+		}
+	}
+	err := fmt.Errorf("%q, expected one of %s", scope, strings.Join(depScopes, ", "))
+	return append(errors, InvalidValueError(prefix+"scope", err, c.positions[prefix+"scope"]))
+}
+
+// Keys of the tree in the order they are declared in the file.
+func (c *Config) sortedKeys(t *toml.TomlTree, prefix string) []string {
+	keys := t.Keys()
+	sort.Slice(keys, func(i, j int) bool {
+		pi, pj := c.positions[prefix+keys[i]], c.positions[prefix+keys[j]]
+		return pi.Line < pj.Line || (pi.Line == pj.Line && keys[i] < keys[j])
+	})
+	return keys
+}
+
+func sortErrors(errors gopack.ProjectErrors) gopack.ProjectErrors {
+	sort.SliceStable(errors, func(i, j int) bool {
+		return errors[i].Position.Line < errors[j].Position.Line
+	})
+	return errors
+}
+
+func hasType(value interface{}, expected string) bool {
+	switch expected {
+	case stringType:
+		_, ok := value.(string)
+		return ok
+	case tableType:
+		_, ok := value.(*toml.TomlTree)
+		return ok
+	case boolType:
+		_, ok := value.(bool)
+		return ok
+	case stringArrayType:
+		array, ok := value.([]interface{})
+		for _, v := range array {
+			if _, isString := v.(string); !isString {
+				return false
+			}
+		}
+		return ok
+	}
+	return false
+}
+
+func typeName(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return stringType
+	case int64:
+		return "integer"
+	case float64:
+		return "float"
+	case bool:
+		return boolType
+	case time.Time:
+		return "datetime"
+	case []interface{}:
+		return "array"
+	case *toml.TomlTree:
+		return tableType
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+// Suggest the known key closest to an unknown one,
+// as long as it's just a typo away.
+func suggest(key string, schema map[string]string) string {
+	known := make([]string, 0, len(schema))
+	for k := range schema {
+		known = append(known, k)
+	}
+	sort.Strings(known)
+
+	best, bestDistance := "", len(key)/2+1
+	for _, k := range known {
+		if d := distance(key, k); d < bestDistance {
+			best, bestDistance = k, d
+		}
+	}
+	return best
+}
+
+// Levenshtein distance between two keys.
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = prev[j] + 1
+			if cur[j-1]+1 < cur[j] {
+				cur[j] = cur[j-1] + 1
+			}
+			if prev[j-1]+cost < cur[j] {
+				cur[j] = prev[j-1] + cost
+			}
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,172 @@
+package config
+
+import (
+	"github.com/d2fn/gopack"
+	"io/ioutil"
+	"testing"
+)
+
+func loadInvalidConfig(t *testing.T, fixture string) gopack.ProjectErrors {
+	dir, err := ioutil.TempDir("", "gopack-config-")
+	check(err)
+	createFixtureConfig(dir, fixture)
+
+	_, err = NewConfig(dir)
+	errors, ok := err.(gopack.ProjectErrors)
+	if !ok {
+		t.Fatalf("Expected schema errors but it was %v\n", err)
+	}
+	for _, e := range errors {
+		t.Log(e)
+	}
+	return errors
+}
+
+func checkSchemaError(t *testing.T, e *gopack.ProjectError, kind string, line int, message string) {
+	if e.Kind != kind {
+		t.Errorf("Expected a %s error but it was %s\n", kind, e.Kind)
+	}
+	if e.Position.Line != line {
+		t.Errorf("Expected the error to point at line %d but it was %d\n", line, e.Position.Line)
+	}
+	if e.Message != message {
+		t.Errorf("Expected message %q but it was %q\n", message, e.Message)
+	}
+}
+
+func TestUnknownKeys(t *testing.T) {
+	errors := loadInvalidConfig(t, `
+repo = "github.com/d2fn/gopack"
+allow_floatin = ["mux"]
+
+[deps.mux]
+  improt = "github.com/gorilla/mux"
+  import = "github.com/gorilla/mux"
+  tag = "v1.0"
+  flavour = "vanilla"
+`)
+
+	if len(errors) != 3 {
+		t.Fatalf("Expected 3 errors but found %d\n", len(errors))
+	}
+
+	checkSchemaError(t, errors[0], gopack.UnknownKey, 3, "unknown key allow_floatin, did you mean allow_floating?\n")
+	checkSchemaError(t, errors[1], gopack.UnknownKey, 6, "unknown key deps.mux.improt, did you mean import?\n")
+	checkSchemaError(t, errors[2], gopack.UnknownKey, 9, "unknown key deps.mux.flavour\n")
+}
+
+func TestInvalidTypes(t *testing.T) {
+	errors := loadInvalidConfig(t, `
+allow_floating = ["mux", 1]
+
+[deps.mux]
+  import = "github.com/gorilla/mux"
+  tag = 1
+`)
+
+	if len(errors) != 2 {
+		t.Fatalf("Expected 2 errors but found %d\n", len(errors))
+	}
+
+	checkSchemaError(t, errors[0], gopack.InvalidType, 2, "allow_floating must be an array of strings, found array\n")
+	checkSchemaError(t, errors[1], gopack.InvalidType, 6, "deps.mux.tag must be a string, found integer\n")
+}
+
+func TestDepsAsArray(t *testing.T) {
+	errors := loadInvalidConfig(t, `deps = ["github.com/gorilla/mux"]`)
+
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 error but found %d\n", len(errors))
+	}
+
+	checkSchemaError(t, errors[0], gopack.InvalidType, 1, "deps must be a table, found array\n")
+}
+
+func TestMissingImport(t *testing.T) {
+	errors := loadInvalidConfig(t, `
+[deps.testgopack]
+  branch = "master"
+`)
+
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 error but found %d\n", len(errors))
+	}
+
+	checkSchemaError(t, errors[0], gopack.MissingImport, 2, "deps.testgopack doesn't specify the import path\n")
+}
+
+func TestDuplicateImports(t *testing.T) {
+	errors := loadInvalidConfig(t, `
+[deps.foo]
+  import = "github.com/calavera/foo"
+  tag = "v1.0.0"
+[deps.bar]
+  import = "github.com/calavera/foo"
+  branch = "master"
+`)
+
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 error but found %d\n", len(errors))
+	}
+
+	checkSchemaError(t, errors[0], gopack.DuplicateImport, 5, "deps.bar imports github.com/calavera/foo, already imported by deps.foo\n")
+}
+
+func TestInvalidPlatforms(t *testing.T) {
+	errors := loadInvalidConfig(t, `
+platforms = ["linux/amd64", "windows"]
+
+[deps.winapi]
+  import = "github.com/golang/winapi"
+  platforms = ["windows/"]
+`)
+
+	if len(errors) != 2 {
+		t.Fatalf("Expected 2 errors but found %d\n", len(errors))
+	}
+
+	checkSchemaError(t, errors[0], gopack.InvalidValue, 2, "invalid platforms: windows is not a GOOS/GOARCH pair\n")
+	checkSchemaError(t, errors[1], gopack.InvalidValue, 6, "invalid deps.winapi.platforms: windows/ is not a GOOS/GOARCH pair\n")
+}
+
+func TestInvalidScope(t *testing.T) {
+	errors := loadInvalidConfig(t, `
+[deps.check]
+  import = "launchpad.net/gocheck"
+  scope = "bench"
+`)
+
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 error but found %d\n", len(errors))
+	}
+
+	checkSchemaError(t, errors[0], gopack.InvalidValue, 4, "invalid deps.check.scope: \"bench\", expected one of test\n")
+}
+
+func TestInvalidIgnorePatterns(t *testing.T) {
+	errors := loadInvalidConfig(t, `
+ignore = ["testdata", "fixtures/[a-"]
+`)
+
+	if len(errors) != 1 {
+		t.Fatalf("Expected 1 error but found %d\n", len(errors))
+	}
+
+	checkSchemaError(t, errors[0], gopack.InvalidValue, 2, "invalid ignore: fixtures/[a- is not a valid pattern\n")
+}
+
+func TestInvalidEnv(t *testing.T) {
+	errors := loadInvalidConfig(t, `
+append_gopath = "yes"
+
+[env]
+  CGO_ENABLED = 0
+`)
+
+	if len(errors) != 2 {
+		t.Fatalf("Expected 2 errors but found %d\n", len(errors))
+	}
+
+	checkSchemaError(t, errors[0], gopack.InvalidType, 2, "append_gopath must be a boolean, found string\n")
+	checkSchemaError(t, errors[1], gopack.InvalidType, 5, "env.CGO_ENABLED must be a string, found integer\n")
+}
@@ -0,0 +1,90 @@
+package deps
+
+import (
+	"fmt"
+	"github.com/d2fn/gopack"
+	"github.com/d2fn/gopack/stats"
+)
+
+func FetchError(d *Dep, err error) *gopack.ProjectError {
+	return &gopack.ProjectError{
+		Kind:     gopack.FetchFailed,
+		Message:  fmt.Sprintf("unable to fetch %s: %s\n", d.Import, err),
+		Position: d.Position,
+	}
+}
+
+func UnusedDependencyError(d *Dep) *gopack.ProjectError {
+	return &gopack.ProjectError{
+		Kind:     gopack.UnusedDep,
+		Message:  fmt.Sprintf("%s in gopack.config is unused\n", d.Import),
+		Position: d.Position,
+	}
+}
+
+func UnmanagedImportError(s *stats.ImportStats) *gopack.ProjectError {
+	msg := fmt.Sprintf("%s referenced in the following locations but not managed in gopack.config\n%s", s.Path, s.ReferenceList())
+	return &gopack.ProjectError{
+		Kind:    gopack.UnmanagedImport,
+		Message: msg,
+	}
+}
+
+func TestDependencyInProductionError(d *Dep, s *stats.ImportStats) *gopack.ProjectError {
+	msg := fmt.Sprintf("%s is a test dependency but production code references it in the following locations\n%s", d.Import, stats.FormatReferences(s.References(stats.Production)))
+	return &gopack.ProjectError{
+		Kind:     gopack.TestDepInProd,
+		Message:  msg,
+		Position: d.Position,
+	}
+}
+
+func MissingPackageError(d *Dep, s *stats.ImportStats) *gopack.ProjectError {
+	msg := fmt.Sprintf("%s doesn't exist in %s at %s, referenced in the following locations\n%s", s.Path, d.Import, d.checkoutName(), s.ReferenceList())
+	return &gopack.ProjectError{
+		Kind:    gopack.MissingPackage,
+		Message: msg,
+	}
+}
+
+func FloatingDependencyError(d *Dep) *gopack.ProjectError {
+	var msg string
+	if d.CheckoutFlag == BranchFlag {
+		msg = fmt.Sprintf("%s points at branch %s, pin it to a tag or a commit\n", d.Import, d.CheckoutSpec)
+	} else {
+		msg = fmt.Sprintf("%s doesn't specify a branch, commit or tag, pin it to a tag or a commit\n", d.Import)
+	}
+
+	return &gopack.ProjectError{
+		Kind:     gopack.FloatingDep,
+		Message:  msg,
+		Position: d.Position,
+		Warning:  true,
+	}
+}
+
+func InvalidCheckoutError(d *Dep) *gopack.ProjectError {
+	return &gopack.ProjectError{
+		Kind:     gopack.InvalidCheckout,
+		Message:  fmt.Sprintf("%s - only one of branch/commit/tag may be specified\n", d.Import),
+		Position: d.Position,
+	}
+}
+
+// The projects of a workspace share their dependencies, so they must agree on them.
+func WorkspaceConflictError(d, other *Dep) *gopack.ProjectError {
+	return &gopack.ProjectError{
+		Kind:     gopack.ConflictingDep,
+		Message:  fmt.Sprintf("%s at %s conflicts with %s declared in %s, the projects of the workspace share it\n", d.Import, d.checkoutName(), other.checkoutName(), other.Position),
+		Position: d.Position,
+	}
+}
+
+func ConflictingDependencyError(d, other *Dep) *gopack.ProjectError {
+	return &gopack.ProjectError{
+		Kind:     gopack.ConflictingDep,
+		Message:  fmt.Sprintf("%s at %s conflicts with %s declared in %s\n", d.Import, d.checkoutName(), other.checkoutName(), other.Position),
+		Position: d.Position,
+		Warning:  true,
+	}
+}
@@ -1,4 +1,4 @@
-package main
+package deps
 
 import (
 	"strings"
@@ -44,6 +44,15 @@ func (graph *Graph) Search(importPath string) *Node {
 	return nil
 }
 
+// Lookup finds the dependency declared with exactly this import path.
+func (graph *Graph) Lookup(importPath string) (*Dep, bool) {
+	node := graph.Search(importPath)
+	if node == nil || node.Dependency == nil || node.Dependency.Import != importPath {
+		return nil, false
+	}
+	return node.Dependency, true
+}
+
 func deepInsert(nodes map[string]*Node, keys []string, dependency *Dep) *Node {
 	node, found := nodes[keys[0]]
 	if found == false {
@@ -0,0 +1,638 @@
+// Package deps models the dependencies of a project declared
+// in its gopack.config, and validates them against its source.
+package deps
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/d2fn/gopack"
+	"github.com/d2fn/gopack/config"
+	"github.com/d2fn/gopack/manifests"
+	"github.com/d2fn/gopack/scm"
+	"github.com/d2fn/gopack/stats"
+	"github.com/pelletier/go-toml"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	ImportProp = "import"
+	BranchProp = scm.Branch
+	CommitProp = scm.Commit
+	TagProp    = scm.Tag
+	ScopeProp  = "scope"
+	TestScope  = "test"
+	BranchFlag = 1 << 0
+	CommitFlag = 1 << 1
+	TagFlag    = 1 << 2
+)
+
+type Dependencies struct {
+	Imports     []string
+	Keys        []string
+	DepList     []*Dep
+	ImportGraph *Graph
+	// Declarations pointing at different code than previous ones.
+	Conflicts []*gopack.ProjectError
+	// What the manifest of another dependency manager declares
+	// that can't be followed, one message each.
+	Warnings []string
+	// Declarations of a workspace left out for an earlier one of the same import.
+	shadowed *Dependencies
+	// whether any of them needs to be fetched
+	fetch bool
+}
+
+type Dep struct {
+	Import string
+	// which of BranchFlag, CommitFlag, TagFlag is this repo
+	CheckoutFlag uint8
+	// the name of the thing to checkout whether it be a commit, branch, or tag
+	CheckoutSpec string
+	// where the dependency is declared in gopack.config
+	Position token.Position
+	// whether the dependency is allowed to track a branch
+	AllowFloating bool
+	// GOOS/GOARCH pairs the dependency is needed for, all of them when empty
+	Platforms []string
+	// TestScope when only tests need the dependency, empty otherwise
+	Scope string
+	// root of the project whose vendor tree holds the dependency
+	Root string
+	// directory of the local project linked in place of the dependency,
+	// like the projects of a workspace, empty when it's fetched
+	Local string
+
+	fetch bool
+}
+
+func NewDependency(repo string) *Dep {
+	return &Dep{Import: repo}
+}
+
+// Link the project's own repository, if the configuration names it,
+// into the vendor tree of the project at root so that it's never fetched.
+func InitRepo(c *config.Config, root string, importGraph *Graph) error {
+	if c.Repository != "" {
+		return linkLocal(c.Repository, root, root, importGraph)
+	}
+	return nil
+}
+
+// Link every project of a workspace into the vendor tree they share at
+// root, so that they are used in place of any dependency on them.
+// Every configuration must name the repository of its project.
+func InitWorkspace(configs []*config.Config, root string, importGraph *Graph) error {
+	for _, c := range configs {
+		if err := linkLocal(c.Repository, filepath.Dir(c.Path), root, importGraph); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func linkLocal(importPath, dir, root string, importGraph *Graph) error {
+	src := filepath.Join(root, gopack.VendorDir, "src")
+	repo := filepath.Join(src, importPath)
+	os.MkdirAll(filepath.Dir(repo), 0755)
+
+	err := os.Symlink(dir, repo)
+	if err != nil && !os.IsExist(err) {
+		return err
+	}
+
+	dependency := NewDependency(importPath)
+	dependency.Root = root
+	dependency.Local = dir
+	importGraph.Insert(dependency)
+	return nil
+}
+
+// Load the dependencies declared in c, to be vendored in the project at root.
+// NeedsFetch tells whether any of them has to be fetched.
+func Load(c *config.Config, root string, importGraph *Graph) (*Dependencies, error) {
+	return load(c, root, importGraph)
+}
+
+// Every dependency of the project at root, the ones declared in c and, for
+// those already fetched, the ones they declare themselves, without
+// fetching anything. The declaration of an import closest to the project
+// wins, and the first one among those as close. The local projects linked
+// in importGraph are used in place of the dependencies on them.
+func Resolve(c *config.Config, root string, importGraph *Graph) ([]*Dep, error) {
+	deps, err := load(c, root, importGraph)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := []*Dep{}
+	seen := make(map[string]bool)
+	// level by level, the project's declarations first
+	level := []*Dependencies{deps}
+	for top := true; len(level) > 0; top = false {
+		next := []*Dependencies{}
+		for _, deps := range level {
+			for _, dep := range deps.DepList {
+				// only the project's own tests run
+				if seen[dep.Import] || (dep.TestOnly() && !top) {
+					continue
+				}
+				seen[dep.Import] = true
+				resolved = append(resolved, dep)
+
+				transitive, err := dep.loadTransitive(importGraph)
+				if err != nil {
+					return nil, err
+				}
+				if transitive != nil {
+					next = append(next, transitive)
+				}
+			}
+		}
+		level = next
+	}
+	return resolved, nil
+}
+
+// Load the dependencies of every project of a workspace together, to be
+// vendored in the tree they share at root, in the order of the configs.
+// The projects must agree on the code they depend on: declarations of an
+// import pointing at different code are conflicts that can't be ignored.
+// NeedsFetch tells whether any of them has to be fetched.
+func LoadWorkspace(configs []*config.Config, root string, importGraph *Graph) (*Dependencies, error) {
+	merged := &Dependencies{ImportGraph: importGraph, shadowed: &Dependencies{}}
+	declared := make(map[string]*Dep)
+
+	for _, c := range configs {
+		deps, err := load(c, root, importGraph)
+		if err != nil {
+			return nil, err
+		}
+
+		merged.fetch = merged.fetch || deps.fetch
+		for i, dep := range deps.DepList {
+			if other, found := declared[dep.Import]; found {
+				if dep.Conflicts(other) {
+					merged.Conflicts = append(merged.Conflicts, WorkspaceConflictError(dep, other))
+				}
+				merged.shadowed.Keys = append(merged.shadowed.Keys, deps.Keys[i])
+				merged.shadowed.Imports = append(merged.shadowed.Imports, dep.Import)
+				merged.shadowed.DepList = append(merged.shadowed.DepList, dep)
+				continue
+			}
+			declared[dep.Import] = dep
+			merged.Keys = append(merged.Keys, deps.Keys[i])
+			merged.Imports = append(merged.Imports, dep.Import)
+			merged.DepList = append(merged.DepList, dep)
+		}
+	}
+	return merged, nil
+}
+
+// The dependencies declared in the configuration file at path, even the
+// ones an earlier project of the workspace declares too, sharing the graph
+// and the conflicts of d.
+func (d *Dependencies) DeclaredIn(path string) *Dependencies {
+	shadowed := make(map[string]int)
+	if d.shadowed != nil {
+		for i, dep := range d.shadowed.DepList {
+			if dep.Position.Filename == path {
+				shadowed[dep.Import] = i
+			}
+		}
+	}
+
+	declared := &Dependencies{ImportGraph: d.ImportGraph, Conflicts: d.Conflicts}
+	for i, dep := range d.DepList {
+		key := d.Keys[i]
+		if j, found := shadowed[dep.Import]; found {
+			dep, key = d.shadowed.DepList[j], d.shadowed.Keys[j]
+		} else if dep.Position.Filename != path {
+			continue
+		}
+		declared.Keys = append(declared.Keys, key)
+		declared.Imports = append(declared.Imports, dep.Import)
+		declared.DepList = append(declared.DepList, dep)
+	}
+	return declared
+}
+
+func load(c *config.Config, root string, importGraph *Graph) (*Dependencies, error) {
+	depsTree := c.DepsTree
+	deps := &Dependencies{ImportGraph: importGraph}
+
+	if depsTree == nil {
+		return deps, nil
+	}
+
+	modifiedChecksum, err := c.ModifiedChecksum(root)
+	if err != nil {
+		return nil, err
+	}
+	deps.fetch = modifiedChecksum
+
+	for _, k := range c.DepKeys() {
+		depTree := depsTree.Get(k).(*toml.TomlTree)
+		importPath, ok := depTree.Get(ImportProp).(string)
+		if !ok {
+			return nil, config.MissingImportError(k, c.Position(k))
+		}
+
+		d := NewDependency(importPath)
+		d.Root = root
+		d.Position = c.Position(k)
+		d.AllowFloating = c.AllowsFloating(k, d.Import)
+		d.Platforms = config.Strings(depTree, "platforms")
+		if scope, ok := depTree.Get(ScopeProp).(string); ok {
+			d.Scope = scope
+		}
+
+		d.setCheckout(depTree, BranchProp, BranchFlag)
+		d.setCheckout(depTree, CommitProp, CommitFlag)
+		d.setCheckout(depTree, TagProp, TagFlag)
+
+		d.useLocal(importGraph)
+
+		if e := d.CheckValidity(); e != nil {
+			return nil, e
+		}
+
+		if d.Fetch(modifiedChecksum) {
+			deps.fetch = true
+		}
+		deps.add(k, d)
+	}
+
+	return deps, nil
+}
+
+// Load the dependencies pinned in the manifest of another dependency manager
+// at path, to be vendored in the project at root. What can't be expressed
+// as a gopack dependency, like version ranges, is left out.
+func loadManifest(path, root string, importGraph *Graph) (*Dependencies, error) {
+	m, err := manifests.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+
+	deps := &Dependencies{ImportGraph: importGraph}
+	for _, problem := range m.Problems {
+		deps.Warnings = append(deps.Warnings, fmt.Sprintf("%s: %s", path, problem))
+	}
+	for _, e := range m.Entries {
+		d := NewDependency(e.Import)
+		d.Root = root
+		// manifests don't tell where each dependency is declared
+		d.Position = token.Position{Filename: path}
+		d.CheckoutFlag = checkoutFlags[e.Kind]
+		d.CheckoutSpec = e.Spec
+		if e.Test {
+			d.Scope = TestScope
+		}
+
+		d.useLocal(importGraph)
+		// like transitive gopack.config files, they are never checksummed
+		d.Fetch(true)
+		deps.add(e.Import, d)
+	}
+	deps.fetch = len(deps.DepList) > 0
+	return deps, nil
+}
+
+var checkoutFlags = map[string]uint8{
+	BranchProp: BranchFlag,
+	CommitProp: CommitFlag,
+	TagProp:    TagFlag,
+}
+
+// Declare the dependency under key, noting if it conflicts
+// with a previous declaration of the same import. The graph
+// keeps the first one, like the local projects linked.
+func (d *Dependencies) add(key string, dep *Dep) {
+	other, found := d.ImportGraph.Lookup(dep.Import)
+	if found && dep.Conflicts(other) {
+		d.Conflicts = append(d.Conflicts, ConflictingDependencyError(dep, other))
+	}
+
+	d.Keys = append(d.Keys, key)
+	d.Imports = append(d.Imports, dep.Import)
+	d.DepList = append(d.DepList, dep)
+	if !found {
+		d.ImportGraph.Insert(dep)
+	}
+}
+
+// Whether any of the dependencies has to be fetched, as the configuration
+// changed since the last run or some of them track a branch.
+func (d *Dependencies) NeedsFetch() bool {
+	return d.fetch
+}
+
+func (d *Dependencies) IncludesDependency(importPath string) (*Node, bool) {
+	node := d.ImportGraph.Search(importPath)
+	return node, node != nil
+}
+
+// Test dependencies are only fetched when tests run, so
+// they are fetched the first time even if the checksum matches.
+// Local projects are never fetched.
+func (d *Dep) Fetch(all bool) bool {
+	d.fetch = d.Local == "" && (all || (d.CheckoutFlag != CommitFlag && d.CheckoutFlag != TagFlag) ||
+		(d.TestOnly() && !d.vendored()))
+	return d.fetch
+}
+
+// A local project linked in the graph replaces the dependency,
+// or the repository holding it, so that it's never fetched.
+func (d *Dep) useLocal(importGraph *Graph) {
+	if node := importGraph.Search(d.Import); node != nil && node.Dependency != nil && node.Dependency.Local != "" {
+		d.Local = node.Dependency.Local
+	}
+}
+
+// Whether the dependency is fetched, as decided by Fetch.
+func (d *Dep) Fetching() bool {
+	return d.fetch
+}
+
+func (d *Dep) vendored() bool {
+	_, err := os.Stat(d.Src())
+	return err == nil
+}
+
+func (d *Dep) setCheckout(t *toml.TomlTree, key string, flag uint8) {
+	s := t.Get(key)
+	if s != nil {
+		d.CheckoutSpec = s.(string)
+		d.CheckoutFlag |= flag
+	}
+}
+
+// A floating dependency tracks a branch, or the default one when
+// no checkout spec is given, so its code can change under your feet.
+func (d *Dep) Floating() bool {
+	return d.CheckoutFlag == BranchFlag || d.CheckoutFlag == 0
+}
+
+func (d *Dep) CheckValidity() *gopack.ProjectError {
+	f := d.CheckoutFlag
+	if f&(f-1) != 0 {
+		return InvalidCheckoutError(d)
+	}
+	return nil
+}
+
+// Whether only tests need the dependency.
+func (d *Dep) TestOnly() bool {
+	return d.Scope == TestScope
+}
+
+// Whether any of the platforms needs the dependency.
+// Dependencies that don't specify platforms are always needed.
+func (d *Dep) NeededFor(platforms []string) bool {
+	if len(d.Platforms) == 0 || len(platforms) == 0 {
+		return true
+	}
+
+	for _, p := range platforms {
+		for _, dp := range d.Platforms {
+			if p == dp {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Two declarations of the same import conflict when they
+// point at different code. Only declarations coming from a
+// gopack.config or a manifest are considered, not the project's own repo.
+func (d *Dep) Conflicts(other *Dep) bool {
+	return other.Position.Filename != "" &&
+		(d.CheckoutFlag != other.CheckoutFlag || d.CheckoutSpec != other.CheckoutSpec)
+}
+
+// Call fn for every dependency, stopping at the first error.
+func (d *Dependencies) VisitDeps(fn func(dep *Dep) error) error {
+	for _, dep := range d.DepList {
+		if err := fn(dep); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Dependencies) String() string {
+	return fmt.Sprintf("imports = %s, keys = %s", d.Imports, d.Keys)
+}
+
+func (d *Dependencies) PrintDependencyTree() {
+	d.ImportGraph.PreOrderVisit(
+		func(n *Node, depth int) {
+			indent := strings.Repeat(" ", depth*2)
+			dep := n.Dependency
+			bullet := "+-"
+			if n.Leaf {
+				bullet = "-"
+			}
+			if dep == nil {
+				fmt.Printf("%s%s %s\n", indent, bullet, n.Key)
+			} else {
+				fmt.Printf("%s%s %s @ %s\n", indent, bullet, dep.Import, dep.CheckoutSpec)
+			}
+		})
+}
+
+func (d *Dep) String() string {
+	if d.CheckoutType() != "" {
+		return fmt.Sprintf("import = %s, %s = %s", d.Import, d.CheckoutType(), d.CheckoutSpec)
+	} else {
+		return fmt.Sprintf("import = %s", d.Import)
+	}
+}
+
+func (d *Dep) checkoutName() string {
+	if d.CheckoutType() == "" {
+		return "its default branch"
+	}
+	return fmt.Sprintf("%s %s", d.CheckoutType(), d.CheckoutSpec)
+}
+
+func (d *Dep) CheckoutType() string {
+	switch d.CheckoutFlag {
+	case BranchFlag:
+		return scm.Branch
+	case TagFlag:
+		return scm.Tag
+	case CommitFlag:
+		return scm.Commit
+	}
+	return ""
+}
+
+func (d *Dep) Src() string {
+	return filepath.Join(d.Root, gopack.VendorDir, "src", d.Import)
+}
+
+// switch the dep to the appropriate branch or tag
+func (d *Dep) SwitchToBranchOrTag() error {
+	s, err := d.Scm()
+	if err != nil {
+		return err
+	}
+
+	err = s.Checkout(d.Src(), d.CheckoutType(), d.CheckoutSpec)
+	if err != nil {
+		return fmt.Errorf("error checking out %s on %s: %s", d.CheckoutSpec, d.Import, err)
+	}
+	return nil
+}
+
+// Tell the scm where the dependency is hosted.
+func (d *Dep) Scm() (scm.Scm, error) {
+	// Traverse the source tree backwards until
+	// it finds the right directory
+	// or it arrives to the base of the import.
+	dir, levels := d.Src(), len(strings.Split(d.Import, "/"))
+	// a local project may sit anywhere in its repository, like the
+	// projects of a workspace, so its real path is traversed whole
+	if d.Local != "" {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			dir = resolved
+		} else {
+			dir = d.Local
+		}
+		levels = len(strings.Split(filepath.ToSlash(dir), "/"))
+	}
+	s, err := scm.Detect(dir, levels)
+	if err != nil {
+		return nil, fmt.Errorf("unknown scm for %s", d.Import)
+	}
+	return s, nil
+}
+
+// update the git repo for this dep, running go get with
+// env, whose GOPATH must point at the vendor tree
+func (d *Dep) GoGetUpdate(env []string) error {
+	if !d.fetch {
+		return nil
+	}
+	cmd := exec.Command("go", "get", "-u", d.Import)
+	cmd.Env = env
+	out, err := cmd.CombinedOutput()
+	// go get checks out the root of a repository without Go files,
+	// like the golang.org/x/net manifests declare, before failing
+	if err != nil && bytes.Contains(out, []byte("no Go files")) && d.vendored() {
+		return nil
+	}
+	return err
+}
+
+// Load the dependencies the dependency declares, nil when it has neither
+// a gopack.config nor a manifest. NeedsFetch tells whether any of them
+// has to be fetched.
+func (d *Dep) LoadTransitiveDeps(importGraph *Graph) (*Dependencies, error) {
+	return d.loadTransitive(importGraph)
+}
+
+// Dependencies are declared in their gopack.config or, when they
+// don't have one, in the manifest of the tool they are managed with,
+// like Godeps.json, glide.lock, Gopkg.lock or go.mod.
+func (d *Dep) loadTransitive(importGraph *Graph) (*Dependencies, error) {
+	if _, err := os.Stat(filepath.Join(d.Src(), gopack.ConfigFile)); err == nil {
+		c, err := config.NewConfig(d.Src())
+		if err != nil {
+			return nil, err
+		}
+		return load(c, d.Root, importGraph)
+	}
+
+	if path, found := manifests.Find(d.Src()); found {
+		return loadManifest(path, d.Root, importGraph)
+	}
+	return nil, nil
+}
+
+func (d *Dependencies) Validate(p *stats.ProjectStats) []*gopack.ProjectError {
+	errors := append([]*gopack.ProjectError{}, d.Conflicts...)
+	includedDeps := make(map[string]*Dep)
+
+	for path, s := range p.ImportStatsByPath {
+		node, found := d.IncludesDependency(path)
+		if s.Remote {
+			if found {
+				dep := node.Dependency
+				includedDeps[dep.Import] = dep
+				if dep.TestOnly() && !s.TestOnly() {
+					errors = append(errors, TestDependencyInProductionError(dep, s))
+				}
+			} else {
+				// report a validation error with the locations in source
+				// where an import is used but unmanaged in gopack.config
+				errors = append(errors, UnmanagedImportError(s))
+			}
+		}
+	}
+
+	for _, dep := range d.DepList {
+		_, found := includedDeps[dep.Import]
+		if !found && !p.IsImportUsed(dep.Import) && dep.NeededFor(p.Platforms()) {
+			errors = append(errors, UnusedDependencyError(dep))
+		}
+
+		if dep.Floating() && !dep.AllowFloating && dep.Local == "" {
+			errors = append(errors, FloatingDependencyError(dep))
+		}
+	}
+	return errors
+}
+
+// Check that every package imported from the dependencies exists
+// in the code checked out, once they have been fetched.
+func (d *Dependencies) ValidatePackages(p *stats.ProjectStats) []*gopack.ProjectError {
+	errors := []*gopack.ProjectError{}
+	paths := make([]string, 0, len(p.ImportStatsByPath))
+	for path := range p.ImportStatsByPath {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		s := p.ImportStatsByPath[path]
+		node, found := d.IncludesDependency(path)
+		if !s.Remote || !found {
+			continue
+		}
+
+		dep := node.Dependency
+		// not fetched, like test dependencies when tests don't run
+		if !dep.vendored() {
+			continue
+		}
+		dir := filepath.Join(dep.Src(), filepath.FromSlash(strings.TrimPrefix(path, dep.Import)))
+		if !isPackage(dir) {
+			errors = append(errors, MissingPackageError(dep, s))
+		}
+	}
+	return errors
+}
+
+// Whether dir holds an importable package, some Go file other than tests.
+func isPackage(dir string) bool {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	for _, f := range files {
+		name := f.Name()
+		if !f.IsDir() && strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, "_test.go") {
+			return true
+		}
+	}
+	return false
+}
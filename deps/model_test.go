@@ -0,0 +1,744 @@
+package deps
+
+import (
+	"github.com/d2fn/gopack"
+	"github.com/d2fn/gopack/config"
+	"github.com/d2fn/gopack/scm"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func check(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
+
+func setupTestRoot() string {
+	dir, err := ioutil.TempDir("", "gopack-deps-")
+	check(err)
+	return dir
+}
+
+func createPath(path string) {
+	err := os.MkdirAll(path, 0700)
+	check(err)
+}
+
+func createFixtureConfig(dir string, fixture string) {
+	err := ioutil.WriteFile(path.Join(dir, gopack.ConfigFile), []byte(fixture), 0644)
+	check(err)
+}
+
+func setupTestConfig(fixture string) (*config.Config, string) {
+	root := setupTestRoot()
+
+	createFixtureConfig(root, fixture)
+	c, err := config.NewConfig(root)
+	check(err)
+	return c, root
+}
+
+func loadTestDependencies(t *testing.T, c *config.Config, root string) *Dependencies {
+	deps, err := Load(c, root, NewGraph())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return deps
+}
+
+func createScmDep(root string, scm string, project string, paths ...string) *Dep {
+	dep := &Dep{Import: project, Root: root}
+	scmPath := path.Join(dep.Src(), scm)
+	createPath(scmPath)
+
+	for _, p := range paths {
+		createPath(path.Join(scmPath, p))
+	}
+
+	return dep
+}
+
+func TestGit(t *testing.T) {
+	dep := createScmDep(setupTestRoot(), ".git", "github.com/d2fn/gopack")
+
+	s, err := dep.Scm()
+	if _, ok := s.(scm.Git); !ok {
+		t.Errorf("Expected scm to be git but it was %s.\n%v", s, err)
+	}
+}
+
+func TestHg(t *testing.T) {
+	dep := createScmDep(setupTestRoot(), ".hg", "code.google.com/p/go")
+
+	s, err := dep.Scm()
+	if _, ok := s.(scm.Hg); !ok {
+		t.Errorf("Expected scm to be hg but it was %s.\n%v", s, err)
+	}
+}
+
+func TestUnknownScm(t *testing.T) {
+	dep := createScmDep(setupTestRoot(), ".svn", "code.google.com/p/project")
+
+	s, err := dep.Scm()
+	if _, ok := s.(scm.Svn); !ok {
+		t.Errorf("Expected scm to be svn but it was %s.\n%v", s, err)
+	}
+}
+
+func TestScmOfWorkspaceProject(t *testing.T) {
+	// the repository of the workspace holds the project a few levels down
+	root := setupTestRoot()
+	createPath(path.Join(root, ".git"))
+	local := path.Join(root, "libs", "go", "auth")
+	createPath(path.Join(local, "tokens"))
+
+	linked := &Dep{Import: "github.com/acme/auth", Root: root, Local: local}
+	createPath(path.Dir(linked.Src()))
+	check(os.Symlink(local, linked.Src()))
+
+	for _, dep := range []*Dep{linked, {Import: "github.com/acme/auth/tokens", Root: root, Local: local}} {
+		s, err := dep.Scm()
+		if _, ok := s.(scm.Git); !ok {
+			t.Errorf("Expected the scm of %s to be git but it was %s.\n%v", dep.Import, s, err)
+		}
+	}
+}
+
+func TestSubPackages(t *testing.T) {
+	dep := createScmDep(setupTestRoot(), ".hg", "code.google.com/p/go", "path/filepath", "io")
+	dep.Import = "code.google.com/p/go/path"
+
+	s, err := dep.Scm()
+	if _, ok := s.(scm.Hg); !ok {
+		t.Errorf("Expected scm to be hg but it was %s.\n%v", s, err)
+	}
+}
+
+func TestCheckValidity(t *testing.T) {
+	dep := &Dep{Import: "github.com/d2fn/gopack", CheckoutFlag: BranchFlag | TagFlag}
+	dep.Position = token.Position{Filename: "gopack.config", Line: 3, Column: 1}
+
+	err := dep.CheckValidity()
+	if err == nil {
+		t.Fatal("Expected dependency with branch and tag to be invalid")
+	}
+
+	expected := "gopack.config:3:1: github.com/d2fn/gopack - only one of branch/commit/tag may be specified\n"
+	if err.String() != expected {
+		t.Errorf("Expected error to be %q but it was %q\n", expected, err.String())
+	}
+
+	dep.CheckoutFlag = TagFlag
+	if dep.CheckValidity() != nil {
+		t.Error("Expected dependency with only a tag to be valid")
+	}
+}
+
+func TestInitRepoWithoutRepo(t *testing.T) {
+	c, root := setupTestConfig(`
+[deps.testgopack]
+  import = "github.com/calavera/testGoPack"
+  branch = "master"
+`)
+
+	graph := NewGraph()
+	if err := InitRepo(c, root, graph); err != nil {
+		t.Fatal(err)
+	}
+
+	src := path.Join(root, gopack.VendorDir, "src")
+	_, err := os.Stat(src)
+
+	if !os.IsNotExist(err) {
+		t.Errorf("Expected vendor to not exist in %s\n", root)
+	}
+}
+
+func TestInitRepo(t *testing.T) {
+	c, root := setupTestConfig(`repo = "github.com/d2fn/gopack"`)
+
+	graph := NewGraph()
+	if err := InitRepo(c, root, graph); err != nil {
+		t.Fatal(err)
+	}
+
+	dep := path.Join(root, gopack.VendorDir, "src", "github.com", "d2fn", "gopack")
+	stat, err := os.Stat(dep)
+
+	if os.IsNotExist(err) || (stat.Mode()&os.ModeSymlink != 0) {
+		t.Errorf("Expected repository %s to be linked in vendor %s\n", c.Repository, root)
+	}
+
+	if graph.Search(c.Repository) == nil {
+		t.Errorf("Expected repository %s to be in the dependencies graph\n", c.Repository)
+	}
+}
+
+func TestFetchDependenciesWithoutChecksum(t *testing.T) {
+	c, root := setupTestConfig(`
+[deps.testgopack]
+  import = "github.com/calavera/testGoPack"
+  branch = "master"
+`)
+
+	if !loadTestDependencies(t, c, root).NeedsFetch() {
+		t.Errorf("Expected to fetch the dependencies when there is no checksum")
+	}
+}
+
+func TestFetchDependenciesWithoutChanges(t *testing.T) {
+	c, root := setupTestConfig(`
+[deps.testgopack]
+  import = "github.com/calavera/testGoPack"
+  commit = "182cae2ee3926a960223d8db4998aa9d57c89788"
+`)
+	c.WriteChecksum(root)
+
+	deps := loadTestDependencies(t, c, root)
+	if deps.NeedsFetch() || len(deps.DepList) != 1 {
+		t.Errorf("Expected to load the dependency with commit flag without fetching it")
+	}
+}
+
+func TestFetchDependenciesWithBranch(t *testing.T) {
+	c, root := setupTestConfig(`
+[deps.testgopack]
+  import = "github.com/calavera/testGoPack"
+  branch = "master"
+`)
+	c.WriteChecksum(root)
+
+	deps := loadTestDependencies(t, c, root)
+	if len(deps.DepList) != 1 {
+		t.Errorf("Expected to load any dependency with branch flag")
+	}
+}
+
+func TestFetchDependenciesWithChanges(t *testing.T) {
+	c, root := setupTestConfig(`
+[deps.testgopack]
+  import = "github.com/calavera/testGoPack"
+  commit = "182cae2ee3926a960223d8db4998aa9d57c89788"
+`)
+
+	c.WriteChecksum(root)
+	c.Checksum = nil
+
+	fixture := `
+[deps.testgopack]
+  import = "github.com/calavera/testGoPack"
+  commit = "182cae2ee3926a960223d8db4998aa9d57c89788"
+[deps.foo]
+  import = "github.com/calavera/foo"
+  branch = "master"
+`
+	createFixtureConfig(root, fixture)
+
+	deps := loadTestDependencies(t, c, root)
+	if len(deps.DepList) != 1 {
+		t.Errorf("Expected to load only the new dependencies")
+	}
+}
+
+func TestFetchWithCommitSpecs(t *testing.T) {
+	c, root := setupTestConfig(`
+[deps.testgopack]
+  import = "github.com/calavera/testGoPack"
+  commit = "182cae2ee3926a960223d8db4998aa9d57c89788"
+[deps.foo]
+  import = "github.com/calavera/foo"
+  branch = "master"
+`)
+	c.WriteChecksum(root)
+
+	deps := loadTestDependencies(t, c, root)
+	if deps.DepList[0].fetch {
+		t.Errorf("Expected to not fetch the commit dependencies")
+	}
+	if !deps.DepList[1].fetch {
+		t.Errorf("Expected to fetch the branch dependencies")
+	}
+}
+
+func TestFetchWithTagSpecs(t *testing.T) {
+	c, root := setupTestConfig(`
+[deps.testgopack]
+  import = "github.com/calavera/testGoPack"
+  tag = "v1.0.0"
+[deps.foo]
+  import = "github.com/calavera/foo"
+  branch = "master"
+`)
+	c.WriteChecksum(root)
+
+	deps := loadTestDependencies(t, c, root)
+	if deps.DepList[0].fetch {
+		t.Errorf("Expected to not fetch the tag dependencies")
+	}
+	if !deps.DepList[1].fetch {
+		t.Errorf("Expected to fetch the branch dependencies")
+	}
+}
+
+func TestFetchWithMixedSpecsIgnoringOrder(t *testing.T) {
+	c, root := setupTestConfig(`
+[deps.foo]
+  import = "github.com/calavera/foo"
+  branch = "master"
+[deps.testgopack]
+  import = "github.com/calavera/testGoPack"
+  commit = "182cae2ee3926a960223d8db4998aa9d57c89788"
+`)
+	c.WriteChecksum(root)
+
+	deps := loadTestDependencies(t, c, root)
+	if !deps.DepList[0].fetch {
+		t.Errorf("Expected to not fetch the commit dependencies")
+	}
+	if deps.DepList[1].fetch {
+		t.Errorf("Expected to fetch the branch dependencies")
+	}
+}
+
+func TestDependencyPositions(t *testing.T) {
+	c, root := setupTestConfig(`
+[deps.testgopack]
+  import = "github.com/calavera/testGoPack"
+  branch = "master"
+
+  [deps.foo]
+  import = "github.com/calavera/foo"
+  tag = "v1.0.0"
+`)
+
+	deps := loadTestDependencies(t, c, root)
+	for _, dep := range deps.DepList {
+		line, column := 2, 1
+		if dep.Import == "github.com/calavera/foo" {
+			line, column = 6, 3
+		}
+		if dep.Position.Line != line || dep.Position.Column != column {
+			t.Errorf("Expected %s to be declared in %d:%d but it was %d:%d\n", dep.Import, line, column, dep.Position.Line, dep.Position.Column)
+		}
+		if dep.Position.Filename != c.Path {
+			t.Errorf("Expected %s to be declared in %s but it was %s\n", dep.Import, c.Path, dep.Position.Filename)
+		}
+	}
+}
+
+func TestAllowFloating(t *testing.T) {
+	c, root := setupTestConfig(`
+allow_floating = ["testgopack", "github.com/calavera/bar"]
+
+[deps.testgopack]
+  import = "github.com/calavera/testGoPack"
+  branch = "master"
+[deps.foo]
+  import = "github.com/calavera/foo"
+  branch = "master"
+[deps.bar]
+  import = "github.com/calavera/bar"
+`)
+
+	deps := loadTestDependencies(t, c, root)
+	for _, dep := range deps.DepList {
+		expected := dep.Import != "github.com/calavera/foo"
+		if dep.AllowFloating != expected {
+			t.Errorf("Expected %s to allow floating to be %v\n", dep.Import, expected)
+		}
+	}
+}
+
+func TestConflictingDependencies(t *testing.T) {
+	c, root := setupTestConfig(`
+[deps.foo]
+  import = "github.com/calavera/foo"
+  tag = "v1.0.0"
+`)
+
+	// declared by another project's gopack.config
+	graph := NewGraph()
+	other := &Dep{Import: "github.com/calavera/foo", CheckoutFlag: BranchFlag, CheckoutSpec: "master"}
+	other.Position = token.Position{Filename: "other/gopack.config", Line: 1, Column: 1}
+	graph.Insert(other)
+
+	deps, err := Load(c, root, graph)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(deps.Conflicts) != 1 {
+		t.Fatalf("Expected 1 conflict but found %d\n", len(deps.Conflicts))
+	}
+
+	e := deps.Conflicts[0]
+	if e.Kind != gopack.ConflictingDep || !e.Position.IsValid() {
+		t.Errorf("Expected a conflict error with its position in gopack.config, got %s\n", e)
+	}
+}
+
+func TestSeveralCheckoutSpecs(t *testing.T) {
+	c, root := setupTestConfig(`
+[deps.testgopack]
+  import = "github.com/calavera/testGoPack"
+  branch = "master"
+  tag = "v1.0.0"
+`)
+
+	_, err := Load(c, root, NewGraph())
+	e, ok := err.(*gopack.ProjectError)
+	if !ok || e.Kind != gopack.InvalidCheckout {
+		t.Fatalf("Expected an invalid checkout error but it was %v\n", err)
+	}
+}
+
+func TestNeededFor(t *testing.T) {
+	dep := &Dep{Import: "github.com/golang/winapi", Platforms: []string{"windows/amd64"}}
+
+	if dep.NeededFor([]string{"linux/amd64"}) {
+		t.Error("Expected windows dependency to not be needed for linux")
+	}
+
+	if !dep.NeededFor([]string{"linux/amd64", "windows/amd64"}) {
+		t.Error("Expected windows dependency to be needed for windows")
+	}
+
+	if !dep.NeededFor(nil) {
+		t.Error("Expected dependency to be needed without target platforms")
+	}
+}
+
+func TestFetchTestDependencies(t *testing.T) {
+	c, root := setupTestConfig(`
+[deps.check]
+  import = "launchpad.net/gocheck"
+  tag = "r2013.09.23"
+  scope = "test"
+`)
+	c.WriteChecksum(root)
+
+	deps := loadTestDependencies(t, c, root)
+	if !deps.NeedsFetch() || !deps.DepList[0].TestOnly() {
+		t.Fatal("Expected to fetch the test dependency until it's vendored")
+	}
+
+	createPath(deps.DepList[0].Src())
+	if loadTestDependencies(t, c, root).NeedsFetch() {
+		t.Error("Expected to not fetch the test dependency once it's vendored")
+	}
+}
+
+func TestResolve(t *testing.T) {
+	c, root := setupTestConfig(`
+[deps.mux]
+  import = "github.com/gorilla/mux"
+  tag = "v1.0.0"
+[deps.check]
+  import = "launchpad.net/gocheck"
+  scope = "test"
+`)
+	c.WriteChecksum(root)
+
+	mux := &Dep{Import: "github.com/gorilla/mux", Root: root}
+	createPath(mux.Src())
+	createFixtureConfig(mux.Src(), `
+[deps.context]
+  import = "github.com/gorilla/context"
+  tag = "v1.0.0"
+[deps.mux]
+  import = "github.com/gorilla/mux"
+  tag = "v1.0.0"
+[deps.assert]
+  import = "github.com/stretchr/assert"
+  scope = "test"
+`)
+
+	resolved, err := Resolve(c, root, NewGraph())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	imports := []string{}
+	for _, dep := range resolved {
+		imports = append(imports, dep.Import)
+	}
+	expected := []string{"github.com/gorilla/mux", "launchpad.net/gocheck", "github.com/gorilla/context"}
+	if len(imports) != len(expected) {
+		t.Fatalf("Expected to resolve %v but it was %v\n", expected, imports)
+	}
+	for i := range expected {
+		if imports[i] != expected[i] {
+			t.Errorf("Expected to resolve %v but it was %v\n", expected, imports)
+		}
+	}
+}
+
+func TestLoadTransitiveManifest(t *testing.T) {
+	c, root := setupTestConfig(`
+[deps.mux]
+  import = "github.com/gorilla/mux"
+  tag = "v1.0.0"
+[deps.context]
+  import = "github.com/gorilla/context"
+  tag = "v1.1.0"
+`)
+	graph := NewGraph()
+	deps, err := Load(c, root, graph)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux := deps.DepList[0]
+	createPath(path.Join(mux.Src(), "Godeps"))
+	err = ioutil.WriteFile(path.Join(mux.Src(), "Godeps", "Godeps.json"), []byte(`{
+	"ImportPath": "github.com/gorilla/mux",
+	"Deps": [
+		{"ImportPath": "github.com/gorilla/context", "Rev": "1ea25387ff6f684839d82767c1733ff4d4d15d0a"},
+		{"ImportPath": "golang.org/x/net/context", "Rev": "a6577fac2d73be281a500b310739095313165611"},
+		{"ImportPath": "golang.org/x/net/html", "Rev": "f2499483f923065a842d38eb4c7f1927e6fc6e6d"}
+	]
+}`), 0644)
+	check(err)
+
+	transitive, err := mux.LoadTransitiveDeps(graph)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transitive == nil || len(transitive.DepList) != 2 {
+		t.Fatalf("Expected the 2 dependencies of Godeps.json but found %v\n", transitive)
+	}
+
+	net := transitive.DepList[1]
+	if net.Import != "golang.org/x/net" || net.CheckoutFlag != CommitFlag || net.CheckoutSpec != "a6577fac2d73be281a500b310739095313165611" {
+		t.Errorf("Expected golang.org/x/net to be pinned to its commit but it was %s\n", net)
+	}
+	if len(transitive.Warnings) != 1 || !strings.Contains(transitive.Warnings[0], "golang.org/x/net is at different revisions") {
+		t.Errorf("Expected a warning about the revisions of golang.org/x/net but it was %v\n", transitive.Warnings)
+	}
+
+	if len(transitive.Conflicts) != 1 {
+		t.Fatalf("Expected github.com/gorilla/context to conflict but found %v\n", transitive.Conflicts)
+	}
+	if e := transitive.Conflicts[0]; e.Position.Filename != path.Join(mux.Src(), "Godeps", "Godeps.json") {
+		t.Errorf("Expected the conflict to point at Godeps.json but it was %s\n", e.Position)
+	}
+}
+
+func TestLoadWorkspace(t *testing.T) {
+	root := setupTestRoot()
+	api, auth := path.Join(root, "api"), path.Join(root, "auth")
+	createPath(api)
+	createPath(auth)
+	createFixtureConfig(api, `
+repo = "github.com/acme/api"
+[deps.auth]
+  import = "github.com/acme/auth"
+  branch = "master"
+[deps.mux]
+  import = "github.com/gorilla/mux"
+  tag = "v1.0.0"
+`)
+	createFixtureConfig(auth, `
+repo = "github.com/acme/auth"
+[deps.mux]
+  import = "github.com/gorilla/mux"
+  tag = "v1.1.0"
+[deps.jwt]
+  import = "github.com/dgrijalva/jwt-go"
+  tag = "v3.0.0"
+`)
+	apiConfig, err := config.NewConfig(api)
+	check(err)
+	authConfig, err := config.NewConfig(auth)
+	check(err)
+	configs := []*config.Config{apiConfig, authConfig}
+
+	graph := NewGraph()
+	if err := InitWorkspace(configs, root, graph); err != nil {
+		t.Fatal(err)
+	}
+	deps, err := LoadWorkspace(configs, root, graph)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	imports := strings.Join(deps.Imports, " ")
+	if imports != "github.com/acme/auth github.com/gorilla/mux github.com/dgrijalva/jwt-go" {
+		t.Fatalf("Expected the dependencies of both projects but found %s\n", imports)
+	}
+	if deps.DepList[0].Local != auth || deps.DepList[0].Fetch(false) {
+		t.Errorf("Expected github.com/acme/auth to be used from %s instead of fetched\n", auth)
+	}
+	if deps.DepList[1].CheckoutSpec != "v1.0.0" {
+		t.Errorf("Expected the declaration of the first project to win but it was %s\n", deps.DepList[1].CheckoutSpec)
+	}
+	if len(deps.Conflicts) != 1 || deps.Conflicts[0].Position.Filename != authConfig.Path || deps.Conflicts[0].Warning {
+		t.Errorf("Expected the github.com/gorilla/mux of %s to be a conflicting error but found %v\n", authConfig.Path, deps.Conflicts)
+	}
+
+	own := deps.DeclaredIn(authConfig.Path)
+	if strings.Join(own.Imports, " ") != "github.com/gorilla/mux github.com/dgrijalva/jwt-go" {
+		t.Errorf("Expected the dependencies declared by %s but found %v\n", authConfig.Path, own.Imports)
+	}
+	if own.DepList[0].Position.Filename != authConfig.Path {
+		t.Errorf("Expected github.com/gorilla/mux to be the one declared in %s but it was %s\n", authConfig.Path, own.DepList[0].Position)
+	}
+}
+
+func TestResolveClosestDeclaration(t *testing.T) {
+	c, root := setupTestConfig(`
+[deps.mux]
+  import = "github.com/gorilla/mux"
+  tag = "v1.0.0"
+[deps.context]
+  import = "github.com/gorilla/context"
+  tag = "v1.1.0"
+`)
+	c.WriteChecksum(root)
+
+	// mux and handlers declare each other, and an older context
+	mux := &Dep{Import: "github.com/gorilla/mux", Root: root}
+	createPath(mux.Src())
+	createFixtureConfig(mux.Src(), `
+[deps.handlers]
+  import = "github.com/gorilla/handlers"
+  tag = "v1.0.0"
+[deps.context]
+  import = "github.com/gorilla/context"
+  tag = "v1.0.0"
+`)
+	handlers := &Dep{Import: "github.com/gorilla/handlers", Root: root}
+	createPath(handlers.Src())
+	createFixtureConfig(handlers.Src(), `
+[deps.mux]
+  import = "github.com/gorilla/mux"
+  tag = "v0.9.0"
+`)
+
+	resolved, err := Resolve(c, root, NewGraph())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	specs := []string{}
+	for _, dep := range resolved {
+		specs = append(specs, dep.Import+"@"+dep.CheckoutSpec)
+	}
+	expected := "github.com/gorilla/mux@v1.0.0 github.com/gorilla/context@v1.1.0 github.com/gorilla/handlers@v1.0.0"
+	if strings.Join(specs, " ") != expected {
+		t.Errorf("Expected the project's declarations to win and the cycle to end but it was %v\n", specs)
+	}
+}
+
+func TestFirstDeclarationInGraph(t *testing.T) {
+	c, root := setupTestConfig(`
+[deps.context]
+  import = "github.com/gorilla/context"
+  tag = "v1.1.0"
+`)
+	graph := NewGraph()
+	if _, err := Load(c, root, graph); err != nil {
+		t.Fatal(err)
+	}
+
+	transitive := &Dependencies{ImportGraph: graph}
+	transitive.add("context", &Dep{Import: "github.com/gorilla/context", CheckoutFlag: TagFlag, CheckoutSpec: "v1.0.0", Position: token.Position{Filename: "Godeps.json"}})
+
+	if dep, _ := graph.Lookup("github.com/gorilla/context"); dep.CheckoutSpec != "v1.1.0" {
+		t.Errorf("Expected the graph to keep the project's declaration but it was %s\n", dep)
+	}
+	if len(transitive.Conflicts) != 1 {
+		t.Errorf("Expected the later declaration to conflict but found %v\n", transitive.Conflicts)
+	}
+}
+
+func TestLoadTransitiveManifestOfLocalProject(t *testing.T) {
+	c, root := setupTestConfig(`
+repo = "github.com/acme/app"
+[deps.mux]
+  import = "github.com/gorilla/mux"
+  tag = "v1.0.0"
+`)
+	graph := NewGraph()
+	if err := InitRepo(c, root, graph); err != nil {
+		t.Fatal(err)
+	}
+	deps, err := Load(c, root, graph)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux := deps.DepList[0]
+	createPath(mux.Src())
+	err = ioutil.WriteFile(path.Join(mux.Src(), "go.mod"), []byte(`module github.com/gorilla/mux
+
+require github.com/acme/app v0.0.0-20200101000000-1ea25387ff6f
+`), 0644)
+	check(err)
+
+	transitive, err := mux.LoadTransitiveDeps(graph)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := transitive.DepList[0]
+	if app.Local != root || app.Fetching() {
+		t.Errorf("Expected the project's own repo to be used from %s instead of fetched but it was %s\n", root, app)
+	}
+}
+
+func TestFetchRepositoryRoot(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the go command is faked with a shell script")
+	}
+	root := setupTestRoot()
+
+	// Godeps.json lists the packages of golang.org/x/net, which has no Go files at its root
+	manifest := path.Join(root, "Godeps.json")
+	err := ioutil.WriteFile(manifest, []byte(`{
+	"ImportPath": "github.com/d2fn/app",
+	"Deps": [
+		{"ImportPath": "golang.org/x/net/context", "Rev": "a6577fac2d73be281a500b310739095313165611"},
+		{"ImportPath": "golang.org/x/net/html", "Rev": "a6577fac2d73be281a500b310739095313165611"}
+	]
+}`), 0644)
+	check(err)
+	deps, err := loadManifest(manifest, root, NewGraph())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deps.DepList) != 1 || deps.DepList[0].Import != "golang.org/x/net" {
+		t.Fatalf("Expected the packages to be fetched with their repository but it was %v\n", deps.Imports)
+	}
+
+	// go get checks golang.org/x/net out, then fails on its root
+	bin := setupTestRoot()
+	err = ioutil.WriteFile(path.Join(bin, "go"), []byte(`#!/bin/sh
+if [ "$3" = golang.org/x/net ]; then
+  mkdir -p "$GOPATH/src/$3/.git" "$GOPATH/src/$3/context"
+fi
+echo "can't load package: package $3: no Go files in $GOPATH/src/$3" >&2
+exit 1
+`), 0755)
+	check(err)
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	env := []string{"PATH=" + os.Getenv("PATH"), "GOPATH=" + path.Join(root, gopack.VendorDir)}
+	net := deps.DepList[0]
+	if err := net.GoGetUpdate(env); err != nil {
+		t.Errorf("Expected golang.org/x/net to be fetched but it failed with %s\n", err)
+	}
+
+	// failures to check the repository out are still reported
+	other := &Dep{Import: "golang.org/x/text", Root: root}
+	other.Fetch(true)
+	if err := other.GoGetUpdate(env); err == nil {
+		t.Error("Expected go get to fail when it doesn't check the repository out")
+	}
+}
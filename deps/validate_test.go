@@ -0,0 +1,131 @@
+package deps
+
+import (
+	"github.com/d2fn/gopack"
+	"github.com/d2fn/gopack/config"
+	"github.com/d2fn/gopack/stats"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUnusedDep(t *testing.T) {
+	errors := findErrors("unused-dep", t)
+	if len(errors) != 1 {
+		t.Fatalf("expected 1 error, found %d\n", len(errors))
+	}
+	e := errors[0]
+	if e.Kind != gopack.UnusedDep {
+		t.Errorf("expected unused dependency error\n")
+	}
+	if e.Position.Line != 3 || e.Position.Column != 1 {
+		t.Errorf("expected unused dependency error at 3:1, found %s\n", e.Position)
+	}
+}
+
+func TestUnmanagedImport(t *testing.T) {
+	errors := findErrors("unmanaged-import", t)
+	if len(errors) != 1 {
+		t.Fatalf("expected 1 error, found %d\n", len(errors))
+	}
+	e := errors[0]
+	if e.Kind != gopack.UnmanagedImport {
+		t.Errorf("expected unmanaged import error\n")
+	}
+}
+
+func TestFloatingDep(t *testing.T) {
+	errors := findErrors("floating-dep", t)
+	if len(errors) != 2 {
+		t.Fatalf("expected 2 errors, found %d\n", len(errors))
+	}
+	for _, e := range errors {
+		if e.Kind != gopack.FloatingDep {
+			t.Errorf("expected floating dependency error\n")
+		}
+		if !e.Warning {
+			t.Errorf("expected floating dependency error to be a warning\n")
+		}
+	}
+}
+
+func TestTestDepInProduction(t *testing.T) {
+	errors := findErrors("test-dep-in-production", t)
+	if len(errors) != 1 {
+		t.Fatalf("expected 1 error, found %d\n", len(errors))
+	}
+	e := errors[0]
+	if e.Kind != gopack.TestDepInProd {
+		t.Errorf("expected test dependency in production error\n")
+	}
+	if !strings.HasSuffix(e.Message, "main.go:5") {
+		t.Errorf("expected only production references to be reported, found %s\n", e.Message)
+	}
+}
+
+// Validate one of the projects in the gopack test-projects directory.
+func findErrors(project string, t *testing.T) []*gopack.ProjectError {
+	dir := filepath.Join("..", gopack.GopackTestProjects, project)
+	c, err := config.NewConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := Load(c, setupTestRoot(), NewGraph())
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := stats.AnalyzeSourceTree(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	errors := d.Validate(p)
+	PrintErrors(errors, t)
+	return errors
+}
+
+func PrintErrors(errors []*gopack.ProjectError, t *testing.T) {
+	for _, e := range errors {
+		t.Logf("%s\n", e.String())
+	}
+}
+
+func TestMissingPackage(t *testing.T) {
+	c, root := setupTestConfig(`
+[deps.mux]
+  import = "github.com/gorilla/mux"
+  tag = "v1.0.0"
+`)
+	d := loadTestDependencies(t, c, root)
+
+	mux := d.DepList[0]
+	createPath(filepath.Join(mux.Src(), "context"))
+	check(ioutil.WriteFile(filepath.Join(mux.Src(), "mux.go"), []byte("package mux\n"), 0644))
+	check(ioutil.WriteFile(filepath.Join(mux.Src(), "context", "context_test.go"), []byte("package context\n"), 0644))
+
+	src := setupTestRoot()
+	check(ioutil.WriteFile(filepath.Join(src, "main.go"), []byte(`package main
+import "github.com/gorilla/mux"
+import "github.com/gorilla/mux/context"
+import "github.com/gorilla/mux/nonexistent"
+`), 0644))
+	p, err := stats.AnalyzeSourceTree(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errors := d.ValidatePackages(p)
+	PrintErrors(errors, t)
+	if len(errors) != 2 {
+		t.Fatalf("expected 2 errors, found %d\n", len(errors))
+	}
+	for i, missing := range []string{"github.com/gorilla/mux/context", "github.com/gorilla/mux/nonexistent"} {
+		e := errors[i]
+		if e.Kind != gopack.MissingPackage || !strings.HasPrefix(e.Message, missing+" doesn't exist") {
+			t.Errorf("expected missing package error for %s, found %s\n", missing, e)
+		}
+		if !strings.Contains(e.Message, "main.go:") {
+			t.Errorf("expected missing package error to point at the source, found %s\n", e)
+		}
+	}
+}
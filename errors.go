@@ -1,38 +1,56 @@
-package main
+package gopack
 
 import (
 	"fmt"
+	"go/token"
+	"strings"
 )
 
 const (
-	UnusedDep       = "unused-dep"
-	UnmanagedImport = "unmanaged-import"
+	UnusedDep        = "unused-dep"
+	UnmanagedImport  = "unmanaged-import"
+	FloatingDep      = "floating-dep"
+	InvalidCheckout  = "invalid-checkout"
+	ConflictingDep   = "conflicting-dep"
+	InvalidConfig    = "invalid-config"
+	MissingImport    = "missing-import"
+	UnknownKey       = "unknown-key"
+	InvalidType      = "invalid-type"
+	InvalidValue     = "invalid-value"
+	DuplicateImport  = "duplicate-import"
+	FetchFailed      = "fetch-failed"
+	TestDepInProd    = "test-dep-in-production"
+	MissingPackage   = "missing-package"
+	InvalidWorkspace = "invalid-workspace"
 )
 
 type ProjectError struct {
 	Kind    string
 	Message string
+	// Where the error originated in gopack.config, if it did.
+	Position token.Position
+	// Warnings are reported but only fail the run in strict mode.
+	Warning bool
 }
 
-func UnusedDependencyError(importPath string) *ProjectError {
-	return &ProjectError{
-		UnusedDep,
-		fmt.Sprintf("%s in gopack.config is unused\n", importPath),
-	}
-}
-
-func UnmanagedImportError(s *ImportStats) *ProjectError {
-	msg := fmt.Sprintf("%s referenced in the following locations but not managed in gopack.config\n%s", s.Path, s.ReferenceList())
-	return &ProjectError{
-		UnmanagedImport,
-		msg,
-	}
-}
+// A list of errors found in the project, usually during validation.
+type ProjectErrors []*ProjectError
 
 func (e *ProjectError) String() string {
+	if e.Position.IsValid() {
+		return fmt.Sprintf("%s: %s", e.Position, e.Message)
+	}
 	return e.Message
 }
 
 func (e *ProjectError) Error() string {
 	return e.String()
 }
+
+func (e ProjectErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.String()
+	}
+	return strings.Join(messages, "")
+}
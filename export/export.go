@@ -0,0 +1,218 @@
+// Package export copies the dependencies of a project to the top-level
+// vendor directory the go tool looks into, recording their revisions.
+package export
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/d2fn/gopack"
+	"github.com/d2fn/gopack/deps"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// What was copied to the vendor directory.
+type Manifest struct {
+	Deps []ManifestDep
+	// Test dependencies left out as they haven't been fetched,
+	// which only happens when the tests run.
+	Skipped []string `json:"-"`
+}
+
+type ManifestDep struct {
+	Import string
+	// Branch, commit or tag declared in gopack.config, if any.
+	Checkout string `json:",omitempty"`
+	Spec     string `json:",omitempty"`
+	// Revision of the working copy that was copied.
+	Revision string
+	// Hash of the files copied, to verify them.
+	Checksum string
+}
+
+// Version control metadata, never copied.
+var scmDirs = map[string]bool{".git": true, ".hg": true, ".svn": true, ".bzr": true}
+
+// Copy the fetched dependencies of the project at root to its vendor
+// directory, replacing the copies made before, and write the manifest.
+func Vendor(root string, dependencies []*deps.Dep) (*Manifest, error) {
+	dest := filepath.Join(root, gopack.ExportDir)
+
+	// drop the dependencies copied before, even the ones not declared anymore
+	if previous, err := ReadManifest(root); err == nil {
+		for _, d := range previous.Deps {
+			if err := os.RemoveAll(filepath.Join(dest, filepath.FromSlash(d.Import))); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	manifest := &Manifest{Deps: []ManifestDep{}}
+	for _, dep := range dependencies {
+		if _, err := os.Stat(dep.Src()); err != nil {
+			if dep.TestOnly() {
+				manifest.Skipped = append(manifest.Skipped, dep.Import)
+				continue
+			}
+			return nil, fmt.Errorf("%s hasn't been fetched, run gp first", dep.Import)
+		}
+
+		s, err := dep.Scm()
+		if err != nil {
+			return nil, err
+		}
+		revision, err := s.Revision(dep.Src())
+		if err != nil {
+			return nil, err
+		}
+
+		// local projects, like the ones of a workspace, are linked in the vendor tree
+		src, err := filepath.EvalSymlinks(dep.Src())
+		if err != nil {
+			return nil, err
+		}
+		target := filepath.Join(dest, filepath.FromSlash(dep.Import))
+		if err := os.RemoveAll(target); err != nil {
+			return nil, err
+		}
+		if err := copyTree(src, target); err != nil {
+			return nil, err
+		}
+		checksum, err := treeChecksum(target)
+		if err != nil {
+			return nil, err
+		}
+
+		manifest.Deps = append(manifest.Deps, ManifestDep{
+			Import:   dep.Import,
+			Checkout: dep.CheckoutType(),
+			Spec:     dep.CheckoutSpec,
+			Revision: revision,
+			Checksum: checksum,
+		})
+	}
+	sort.Slice(manifest.Deps, func(i, j int) bool { return manifest.Deps[i].Import < manifest.Deps[j].Import })
+
+	return manifest, manifest.write(root)
+}
+
+// Imports of the dependencies whose copy changed since it was made.
+func Verify(root string) ([]string, error) {
+	manifest, err := ReadManifest(root)
+	if err != nil {
+		return nil, err
+	}
+
+	modified := []string{}
+	for _, d := range manifest.Deps {
+		checksum, err := treeChecksum(filepath.Join(root, gopack.ExportDir, filepath.FromSlash(d.Import)))
+		if err != nil || checksum != d.Checksum {
+			modified = append(modified, d.Import)
+		}
+	}
+	return modified, nil
+}
+
+func ReadManifest(root string) (*Manifest, error) {
+	dat, err := ioutil.ReadFile(filepath.Join(root, gopack.ExportManifest))
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{}
+	if err := json.Unmarshal(dat, manifest); err != nil {
+		return nil, fmt.Errorf("invalid %s: %s", gopack.ExportManifest, err)
+	}
+	return manifest, nil
+}
+
+func (m *Manifest) write(root string) error {
+	dat, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(root, gopack.ExportManifest)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(dat, '\n'), 0644)
+}
+
+// Copy the regular files of the tree, leaving the version control
+// metadata out, and the .gopack directory of local projects.
+func copyTree(src, dest string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && (scmDirs[info.Name()] || info.Name() == gopack.GopackDir) {
+			return filepath.SkipDir
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dest, rel)
+
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(target, 0755)
+		case info.Mode().IsRegular():
+			return copyFile(path, target, info.Mode())
+		}
+		return nil
+	})
+}
+
+func copyFile(src, dest string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode.Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// Hash the paths and contents of the files in the tree.
+func treeChecksum(dir string) (string, error) {
+	h := sha256.New()
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		fmt.Fprintf(h, "%s\x00", filepath.ToSlash(rel))
+		_, err = io.Copy(h, f)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,172 @@
+package export
+
+import (
+	"github.com/d2fn/gopack"
+	"github.com/d2fn/gopack/deps"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func check(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
+
+func git(t *testing.T, dir string, args ...string) {
+	args = append([]string{"-c", "user.name=gopack", "-c", "user.email=gopack@example.com"}, args...)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %s failed: %s\n%s", args, err, out)
+	}
+}
+
+// A project with a dependency fetched in its vendor tree.
+func setupTestProject(t *testing.T) (string, *deps.Dep) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	root, err := ioutil.TempDir("", "gopack-export-")
+	check(err)
+
+	dep := &deps.Dep{Import: "github.com/gorilla/mux", Root: root, CheckoutFlag: deps.TagFlag, CheckoutSpec: "v1.0.0"}
+	fetchTestDep(t, dep)
+	return root, dep
+}
+
+// Check the dependency out in the vendor tree, as a git repository.
+func fetchTestDep(t *testing.T, dep *deps.Dep) {
+	name := filepath.Base(dep.Import)
+	check(os.MkdirAll(filepath.Join(dep.Src(), "context"), 0755))
+	check(ioutil.WriteFile(filepath.Join(dep.Src(), name+".go"), []byte("package "+name+"\n"), 0644))
+	check(ioutil.WriteFile(filepath.Join(dep.Src(), "context", "context.go"), []byte("package context\n"), 0644))
+	git(t, dep.Src(), "init", "-q")
+	git(t, dep.Src(), "add", "-A")
+	git(t, dep.Src(), "commit", "-q", "-m", name)
+}
+
+func TestVendor(t *testing.T) {
+	root, dep := setupTestProject(t)
+
+	manifest, err := Vendor(root, []*deps.Dep{dep})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	target := filepath.Join(root, gopack.ExportDir, "github.com", "gorilla", "mux")
+	if _, err := os.Stat(filepath.Join(target, "context", "context.go")); err != nil {
+		t.Errorf("Expected the dependency to be copied to %s\n", target)
+	}
+	if _, err := os.Stat(filepath.Join(target, ".git")); !os.IsNotExist(err) {
+		t.Error("Expected to leave the version control metadata out")
+	}
+
+	read, err := ReadManifest(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read.Deps) != 1 || read.Deps[0] != manifest.Deps[0] {
+		t.Fatalf("Expected the manifest to be written, but it was %v\n", read.Deps)
+	}
+	d := read.Deps[0]
+	if len(d.Revision) != 40 || d.Checkout != "tag" || d.Spec != "v1.0.0" {
+		t.Errorf("Expected the manifest to record the revision and the tag, but it was %v\n", d)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	root, dep := setupTestProject(t)
+
+	if _, err := Vendor(root, []*deps.Dep{dep}); err != nil {
+		t.Fatal(err)
+	}
+
+	modified, err := Verify(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(modified) != 0 {
+		t.Errorf("Expected the copy to match the manifest, but %v changed\n", modified)
+	}
+
+	mux := filepath.Join(root, gopack.ExportDir, "github.com", "gorilla", "mux", "mux.go")
+	check(ioutil.WriteFile(mux, []byte("package mux // patched\n"), 0644))
+
+	modified, err = Verify(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(modified) != 1 || modified[0] != dep.Import {
+		t.Errorf("Expected %s to be modified, but it was %v\n", dep.Import, modified)
+	}
+}
+
+func TestVendorDropsStaleDependencies(t *testing.T) {
+	root, dep := setupTestProject(t)
+
+	if _, err := Vendor(root, []*deps.Dep{dep}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Vendor(root, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(root, gopack.ExportDir, "github.com", "gorilla", "mux")); !os.IsNotExist(err) {
+		t.Error("Expected to remove the dependencies not exported anymore")
+	}
+}
+
+func TestVendorSkipsUnfetchedTestDependencies(t *testing.T) {
+	root, dep := setupTestProject(t)
+	gocheck := &deps.Dep{Import: "launchpad.net/gocheck", Root: root, Scope: deps.TestScope}
+
+	manifest, err := Vendor(root, []*deps.Dep{dep, gocheck})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Deps) != 1 || len(manifest.Skipped) != 1 || manifest.Skipped[0] != gocheck.Import {
+		t.Errorf("Expected to leave %s out until the tests fetch it, but it was %v %v\n", gocheck.Import, manifest.Deps, manifest.Skipped)
+	}
+
+	context := &deps.Dep{Import: "github.com/gorilla/context", Root: root}
+	if _, err := Vendor(root, []*deps.Dep{dep, context}); err == nil {
+		t.Errorf("Expected to fail when %s hasn't been fetched\n", context.Import)
+	}
+}
+
+func TestVendorWorkspaceProject(t *testing.T) {
+	root, dep := setupTestProject(t)
+
+	// a project of the workspace, linked in the vendor tree
+	lib, err := ioutil.TempDir("", "gopack-export-lib-")
+	check(err)
+	local := &deps.Dep{Import: "github.com/acme/lib", Root: lib}
+	fetchTestDep(t, local)
+	check(os.MkdirAll(filepath.Join(local.Src(), gopack.GopackDir), 0755))
+	check(ioutil.WriteFile(filepath.Join(local.Src(), gopack.GopackDir, "checksum"), []byte("sum"), 0644))
+
+	linked := &deps.Dep{Import: local.Import, Root: root, Local: local.Src()}
+	check(os.MkdirAll(filepath.Dir(linked.Src()), 0755))
+	check(os.Symlink(local.Src(), linked.Src()))
+
+	manifest, err := Vendor(root, []*deps.Dep{dep, linked})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Deps) != 2 {
+		t.Fatalf("Expected both dependencies to be copied but it was %v\n", manifest.Deps)
+	}
+
+	target := filepath.Join(root, gopack.ExportDir, "github.com", "acme", "lib")
+	if info, err := os.Lstat(filepath.Join(target, "lib.go")); err != nil || !info.Mode().IsRegular() {
+		t.Errorf("Expected the project to be copied to %s\n", target)
+	}
+	if _, err := os.Stat(filepath.Join(target, gopack.GopackDir)); !os.IsNotExist(err) {
+		t.Errorf("Expected to leave the %s directory of the project out\n", gopack.GopackDir)
+	}
+}
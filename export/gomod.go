@@ -0,0 +1,333 @@
+package export
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"github.com/d2fn/gopack"
+	"github.com/d2fn/gopack/deps"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+const (
+	GoModFile = "go.mod"
+	GoSumFile = "go.sum"
+)
+
+// A requirement of the go.mod file.
+type Requirement struct {
+	Path    string
+	Version string
+	// Required by the dependencies rather than by the project itself.
+	Indirect bool
+	// Directory of the local project replacing the module,
+	// empty when the module is fetched.
+	Replace string
+	// directory of the module in the vendor tree, empty when it hasn't been fetched
+	dir string
+}
+
+var (
+	// the canonical semantic versions the go tool accepts, without build metadata
+	semver      = regexp.MustCompile(`^v(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)\.(0|[1-9][0-9]*)(-[0-9A-Za-z.-]+)?$`)
+	majorSuffix = regexp.MustCompile(`/v([0-9]+)$`)
+	// gopkg.in paths end with their major version, even v0 and v1
+	gopkgInSuffix = regexp.MustCompile(`^gopkg\.in/.*\.v([0-9]+)$`)
+)
+
+// The version go mod tidy requires the modules replaced by a directory at.
+const localVersion = "v0.0.0-00010101000000-000000000000"
+
+// Translate the dependencies to requirements of the modules holding them.
+// Tags that are canonical semantic versions of the module are kept, anything
+// else, like 1.2.0, is pinned to a pseudo-version of the revision checked out
+// in the vendor tree. Local projects are replaced by their directory. Only
+// the modules of direct dependencies are required by the project, the rest
+// are indirect.
+func Requirements(dependencies []*deps.Dep, direct []string) ([]Requirement, error) {
+	isDirect := make(map[string]bool)
+	for _, d := range direct {
+		isDirect[d] = true
+	}
+
+	requirements := []Requirement{}
+	modules := make(map[string]int)
+	for _, dep := range dependencies {
+		path, dir := module(dep)
+		// the packages of a module share its checkout
+		if i, found := modules[path]; found {
+			requirements[i].Indirect = requirements[i].Indirect && !isDirect[dep.Import]
+			continue
+		}
+
+		r := Requirement{Path: path, Version: localVersion, Indirect: !isDirect[dep.Import], dir: dir}
+		if dep.Local != "" {
+			r.Replace = dir
+		} else {
+			version, err := moduleVersion(dep, path)
+			if err != nil {
+				return nil, err
+			}
+			r.Version = version
+		}
+		modules[path] = len(requirements)
+		requirements = append(requirements, r)
+	}
+	sort.Slice(requirements, func(i, j int) bool { return requirements[i].Path < requirements[j].Path })
+	return requirements, nil
+}
+
+// The path and the directory of the module holding the package of the
+// dependency: the closest directory with a go.mod, up to the root of the
+// repository it's checked out from, or the local project linked in its
+// place. Dependencies that haven't been fetched are taken for modules.
+func module(dep *deps.Dep) (string, string) {
+	src := filepath.Join(dep.Root, gopack.VendorDir, "src")
+	for importPath := dep.Import; strings.Contains(importPath, "/"); importPath = path.Dir(importPath) {
+		dir := filepath.Join(src, filepath.FromSlash(importPath))
+		if dep.Local != "" {
+			if info, err := os.Lstat(dir); err == nil && info.Mode()&os.ModeSymlink != 0 {
+				return importPath, dep.Local
+			}
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dir, GoModFile)); err == nil {
+			return importPath, dir
+		}
+		for name := range scmDirs {
+			if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				return importPath, dir
+			}
+		}
+	}
+
+	if dep.Local != "" {
+		return dep.Import, dep.Local
+	}
+	if _, err := os.Stat(dep.Src()); err != nil {
+		return dep.Import, ""
+	}
+	return dep.Import, dep.Src()
+}
+
+func moduleVersion(dep *deps.Dep, path string) (string, error) {
+	if m := semver.FindStringSubmatch(dep.CheckoutSpec); m != nil && dep.CheckoutFlag == deps.TagFlag {
+		major := majorVersion(path)
+		// modules past v1 without the major version in their path
+		if major == "" && m[1] != "0" && m[1] != "1" {
+			return dep.CheckoutSpec + "+incompatible", nil
+		}
+		// tags of another major version than the one of the path aren't the module's
+		if major == "" || major == m[1] {
+			return dep.CheckoutSpec, nil
+		}
+	}
+	return pseudoVersion(dep, path)
+}
+
+// The major version at the end of a module path, like 2 in
+// example.com/mod/v2 or gopkg.in/yaml.v2, empty when there's none.
+func majorVersion(path string) string {
+	if m := majorSuffix.FindStringSubmatch(path); m != nil {
+		return m[1]
+	}
+	if m := gopkgInSuffix.FindStringSubmatch(path); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// Pseudo-version of the revision the dependency is checked
+// out at, in the major version of the module at path.
+func pseudoVersion(dep *deps.Dep, path string) (string, error) {
+	if _, err := os.Stat(dep.Src()); err != nil {
+		return "", fmt.Errorf("%s hasn't been fetched, run gp first", dep.Import)
+	}
+
+	s, err := dep.Scm()
+	if err != nil {
+		return "", err
+	}
+	revision, err := s.Revision(dep.Src())
+	if err != nil {
+		return "", err
+	}
+	t, err := s.Time(dep.Src())
+	if err != nil {
+		return "", err
+	}
+
+	if len(revision) > 12 {
+		revision = revision[:12]
+	}
+	major := majorVersion(path)
+	// gopkg.in/mod.v1 takes v0 pseudo-versions too
+	if major == "" || major == "1" {
+		major = "0"
+	}
+	return fmt.Sprintf("v%s.0.0-%s-%s", major, t.UTC().Format("20060102150405"), revision), nil
+}
+
+// Write the go.mod of the module, and a go.sum
+// with the hashes of the dependencies fetched.
+func WriteGoMod(root, module string, dependencies []*deps.Dep, direct []string) ([]Requirement, error) {
+	if module == "" {
+		return nil, fmt.Errorf("the module path is unknown, set repo in gopack.config")
+	}
+
+	requirements, err := Requirements(dependencies, direct)
+	if err != nil {
+		return nil, err
+	}
+
+	var mod bytes.Buffer
+	fmt.Fprintf(&mod, "module %s\n", module)
+	if version := goVersion(); version != "" {
+		fmt.Fprintf(&mod, "\ngo %s\n", version)
+	}
+	if len(requirements) > 0 {
+		fmt.Fprint(&mod, "\nrequire (\n")
+		for _, r := range requirements {
+			comment := ""
+			if r.Indirect {
+				comment = " // indirect"
+			}
+			fmt.Fprintf(&mod, "\t%s %s%s\n", r.Path, r.Version, comment)
+		}
+		fmt.Fprint(&mod, ")\n")
+	}
+	replaced := false
+	for _, r := range requirements {
+		if r.Replace == "" {
+			continue
+		}
+		if !replaced {
+			fmt.Fprint(&mod, "\nreplace (\n")
+			replaced = true
+		}
+		fmt.Fprintf(&mod, "\t%s => %s\n", r.Path, replacePath(root, r.Replace))
+	}
+	if replaced {
+		fmt.Fprint(&mod, ")\n")
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, GoModFile), mod.Bytes(), 0644); err != nil {
+		return nil, err
+	}
+
+	var sum bytes.Buffer
+	for _, r := range requirements {
+		// modules that aren't fetched can't be hashed, and
+		// the go command doesn't hash the local ones
+		if r.dir == "" || r.Replace != "" {
+			continue
+		}
+		src, err := filepath.EvalSymlinks(r.dir)
+		if err != nil {
+			return nil, err
+		}
+		lines, err := sumLines(r, src)
+		if err != nil {
+			return nil, err
+		}
+		sum.WriteString(lines)
+	}
+	return requirements, ioutil.WriteFile(filepath.Join(root, GoSumFile), sum.Bytes(), 0644)
+}
+
+// The directory replacing a module, relative to the module at root
+// and starting with ./ or ../ as the go command needs.
+func replacePath(root, dir string) string {
+	rel, err := filepath.Rel(root, dir)
+	if err != nil {
+		return dir
+	}
+	rel = filepath.ToSlash(rel)
+	if rel != ".." && !strings.HasPrefix(rel, "../") {
+		rel = "./" + rel
+	}
+	return rel
+}
+
+// Major and minor version of the go tool gp was built with.
+func goVersion() string {
+	parts := strings.Split(strings.TrimPrefix(runtime.Version(), "go"), ".")
+	if len(parts) < 2 || !strings.HasPrefix(runtime.Version(), "go") {
+		return ""
+	}
+	return parts[0] + "." + parts[1]
+}
+
+// The go.sum lines of the module in src, hashing the files the
+// module zip would hold the same way the go tool does.
+func sumLines(r Requirement, src string) (string, error) {
+	prefix := r.Path + "@" + r.Version + "/"
+	sums := make(map[string][sha256.Size]byte)
+	err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path == src {
+				return nil
+			}
+			// nested modules and vendored code aren't part of the module
+			if scmDirs[info.Name()] || info.Name() == "vendor" {
+				return filepath.SkipDir
+			}
+			if _, err := os.Stat(filepath.Join(path, GoModFile)); err == nil {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		dat, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		sums[prefix+filepath.ToSlash(rel)] = sha256.Sum256(dat)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// modules without a go.mod get one with just the module path
+	goMod := []byte(fmt.Sprintf("module %s\n", r.Path))
+	if dat, err := ioutil.ReadFile(filepath.Join(src, GoModFile)); err == nil {
+		goMod = dat
+	}
+	modHash := hash1(map[string][sha256.Size]byte{GoModFile: sha256.Sum256(goMod)})
+
+	return fmt.Sprintf("%s %s %s\n%s %s/go.mod %s\n", r.Path, r.Version, hash1(sums), r.Path, r.Version, modHash), nil
+}
+
+// The h1 hash of the go tool: the sha256 of the sorted
+// list of the sha256 of every file along with its name.
+func hash1(sums map[string][sha256.Size]byte) string {
+	names := make([]string, 0, len(sums))
+	for name := range sums {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	for _, name := range names {
+		fmt.Fprintf(h, "%x  %s\n", sums[name], name)
+	}
+	return "h1:" + base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
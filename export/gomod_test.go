@@ -0,0 +1,148 @@
+package export
+
+import (
+	"fmt"
+	"github.com/d2fn/gopack"
+	"github.com/d2fn/gopack/deps"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestRequirements(t *testing.T) {
+	root, mux := setupTestProject(t)
+	mux.CheckoutFlag, mux.CheckoutSpec = deps.BranchFlag, "master"
+
+	// tags that aren't canonical versions are pinned to pseudo-versions too
+	toml := &deps.Dep{Import: "github.com/pelletier/go-toml", Root: root, CheckoutFlag: deps.TagFlag, CheckoutSpec: "1.2.0"}
+	fetchTestDep(t, toml)
+	cli := &deps.Dep{Import: "github.com/urfave/cli/v2", Root: root, CheckoutFlag: deps.TagFlag, CheckoutSpec: "v1.22.5"}
+	fetchTestDep(t, cli)
+
+	dependencies := []*deps.Dep{
+		mux,
+		toml,
+		cli,
+		{Import: "github.com/BurntSushi/toml", Root: root, CheckoutFlag: deps.TagFlag, CheckoutSpec: "v1.2.0"},
+		{Import: "github.com/coreos/etcd", Root: root, CheckoutFlag: deps.TagFlag, CheckoutSpec: "v3.3.10"},
+		{Import: "github.com/go-redis/redis/v8", Root: root, CheckoutFlag: deps.TagFlag, CheckoutSpec: "v8.11.0"},
+	}
+
+	requirements, err := Requirements(dependencies, []string{"github.com/gorilla/mux", "github.com/pelletier/go-toml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"github.com/BurntSushi/toml":   "v1.2.0",
+		"github.com/coreos/etcd":       "v3.3.10+incompatible",
+		"github.com/go-redis/redis/v8": "v8.11.0",
+	}
+	pseudo := map[string]string{
+		mux.Import:  "v0",
+		toml.Import: "v0",
+		cli.Import:  "v2",
+	}
+	for _, r := range requirements {
+		if major, found := pseudo[r.Path]; found {
+			if !regexp.MustCompile(`^` + major + `\.0\.0-[0-9]{14}-[0-9a-f]{12}$`).MatchString(r.Version) {
+				t.Errorf("Expected %s to be pinned to a %s pseudo-version but it was %s\n", r.Path, major, r.Version)
+			}
+		} else if r.Version != expected[r.Path] {
+			t.Errorf("Expected %s to be required at %s but it was %s\n", r.Path, expected[r.Path], r.Version)
+		}
+
+		indirect := r.Path != mux.Import && r.Path != toml.Import
+		if r.Indirect != indirect {
+			t.Errorf("Expected %s to be indirect %v\n", r.Path, indirect)
+		}
+	}
+}
+
+func TestWriteGoMod(t *testing.T) {
+	root, mux := setupTestProject(t)
+
+	if _, err := WriteGoMod(root, "", []*deps.Dep{mux}, nil); err == nil {
+		t.Error("Expected to need the module path")
+	}
+
+	if _, err := WriteGoMod(root, "github.com/d2fn/app", []*deps.Dep{mux}, []string{mux.Import}); err != nil {
+		t.Fatal(err)
+	}
+
+	mod, err := ioutil.ReadFile(filepath.Join(root, GoModFile))
+	check(err)
+	if !strings.HasPrefix(string(mod), "module github.com/d2fn/app\n") || !strings.Contains(string(mod), "\tgithub.com/gorilla/mux v1.0.0\n") {
+		t.Errorf("Expected go.mod to require mux at v1.0.0 but it was\n%s", mod)
+	}
+
+	sum, err := ioutil.ReadFile(filepath.Join(root, GoSumFile))
+	check(err)
+	lines := strings.Split(strings.TrimSpace(string(sum)), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "github.com/gorilla/mux v1.0.0 h1:") || !strings.HasPrefix(lines[1], "github.com/gorilla/mux v1.0.0/go.mod h1:") {
+		t.Errorf("Expected go.sum to hash the vendored tree and its go.mod but it was\n%s", sum)
+	}
+}
+
+func TestWriteGoModOfModules(t *testing.T) {
+	root, mux := setupTestProject(t)
+
+	// a package of the mux repository, and a project of the workspace linked in the vendor tree
+	context := &deps.Dep{Import: "github.com/gorilla/mux/context", Root: root, CheckoutFlag: deps.TagFlag, CheckoutSpec: "v1.0.0"}
+	yaml := &deps.Dep{Import: "gopkg.in/yaml.v2", Root: root, CheckoutFlag: deps.TagFlag, CheckoutSpec: "v2.4.0"}
+	lib, err := ioutil.TempDir("", "gopack-export-lib-")
+	check(err)
+	linked := &deps.Dep{Import: "github.com/acme/lib/auth", Root: root, Local: lib}
+	check(os.MkdirAll(filepath.Join(root, gopack.VendorDir, "src", "github.com", "acme"), 0755))
+	check(os.Symlink(lib, filepath.Join(root, gopack.VendorDir, "src", "github.com", "acme", "lib")))
+
+	dependencies := []*deps.Dep{mux, context, yaml, linked}
+	requirements, err := WriteGoMod(root, "github.com/d2fn/app", dependencies, []string{context.Import, linked.Import})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Requirement{
+		{Path: "github.com/acme/lib", Version: localVersion, Replace: lib, dir: lib},
+		{Path: "github.com/gorilla/mux", Version: "v1.0.0", dir: mux.Src()},
+		{Path: "gopkg.in/yaml.v2", Version: "v2.4.0", Indirect: true},
+	}
+	if !reflect.DeepEqual(requirements, expected) {
+		t.Errorf("Expected a requirement per module %v but it was %v\n", expected, requirements)
+	}
+
+	mod, err := ioutil.ReadFile(filepath.Join(root, GoModFile))
+	check(err)
+	if replace := fmt.Sprintf("\nreplace (\n\tgithub.com/acme/lib => %s\n)\n", replacePath(root, lib)); !strings.HasSuffix(string(mod), replace) {
+		t.Errorf("Expected go.mod to replace the local project with its directory but it was\n%s", mod)
+	}
+	if !strings.HasPrefix(replacePath(root, lib), "../") {
+		t.Errorf("Expected the local project to be replaced by a relative path but it was %s\n", replacePath(root, lib))
+	}
+
+	sum, err := ioutil.ReadFile(filepath.Join(root, GoSumFile))
+	check(err)
+	lines, err := sumLines(expected[1], mux.Src())
+	check(err)
+	if string(sum) != lines || !strings.Contains(lines, "github.com/gorilla/mux v1.0.0 h1:") {
+		t.Errorf("Expected go.sum to hash the whole mux repository but it was\n%s", sum)
+	}
+}
+
+func TestMajorVersion(t *testing.T) {
+	for path, expected := range map[string]string{
+		"github.com/gorilla/mux":       "",
+		"github.com/go-redis/redis/v8": "8",
+		"gopkg.in/yaml.v2":             "2",
+		"gopkg.in/check.v1":            "1",
+		"github.com/acme/lib.v2":       "",
+	} {
+		if major := majorVersion(path); major != expected {
+			t.Errorf("Expected the major version of %s to be %q but it was %q\n", path, expected, major)
+		}
+	}
+}
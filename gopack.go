@@ -0,0 +1,18 @@
+// Package gopack holds what is shared by every part of gopack:
+// its version, the layout of the .gopack directory and the errors
+// reported about a project.
+package gopack
+
+const (
+	Version            = "0.20.dev"
+	GopackDir          = ".gopack"
+	GopackChecksum     = ".gopack/checksum"
+	GopackChecksums    = ".gopack/checksums"
+	GopackStatsCache   = ".gopack/stats-cache"
+	GopackTestProjects = ".gopack/test-projects"
+	VendorDir          = ".gopack/vendor"
+	ConfigFile         = "gopack.config"
+	WorkspaceFile      = "gopack.workspace"
+	ExportDir          = "vendor"
+	ExportManifest     = "vendor/gopack.manifest"
+)
@@ -0,0 +1,270 @@
+// Package history follows the dependencies of a project
+// across the commits of its git repository.
+package history
+
+import (
+	"encoding/csv"
+	"fmt"
+	"github.com/d2fn/gopack"
+	"github.com/d2fn/gopack/config"
+	"github.com/d2fn/gopack/scm"
+	"github.com/d2fn/gopack/stats"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// The dependencies of the project at a revision.
+type Snapshot struct {
+	Revision scm.Revision
+	// References in the source to each dependency declared in gopack.config.
+	Deps map[string]int
+	// Branch, tag or commit each dependency is checked out at, like
+	// "tag v1.0.0", empty when it follows its default branch.
+	Checkouts map[string]string
+}
+
+// Snapshots of the project, oldest first.
+type Timeline struct {
+	Snapshots []*Snapshot
+	// Revisions left out as their configuration or their source
+	// couldn't be read.
+	Skipped []*RevisionError
+}
+
+// Why a revision was left out of the timeline.
+type RevisionError struct {
+	Revision scm.Revision
+	Err      error
+}
+
+func (e *RevisionError) Error() string {
+	return fmt.Sprintf("%s %s: %s", e.Revision.Short(), e.Revision.Subject, e.Err)
+}
+
+// Analyze up to samples revisions of the project in dir, spread across
+// its history and always including the first and the last ones. env is
+// the environment of the go command, as in stats.Options.
+func Analyze(dir string, samples int, env []string) (*Timeline, error) {
+	g := scm.Git{}
+	revisions, err := g.Revisions(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	timeline := &Timeline{}
+	for _, rev := range sample(revisions, samples) {
+		snapshot, err := analyzeRevision(g, dir, rev, env)
+		if e, ok := err.(*RevisionError); ok {
+			timeline.Skipped = append(timeline.Skipped, e)
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		timeline.Snapshots = append(timeline.Snapshots, snapshot)
+	}
+	return timeline, nil
+}
+
+func sample(revisions []scm.Revision, samples int) []scm.Revision {
+	if samples <= 0 || len(revisions) <= samples {
+		return revisions
+	}
+	if samples == 1 {
+		return revisions[len(revisions)-1:]
+	}
+
+	sampled := make([]scm.Revision, samples)
+	for i := range sampled {
+		sampled[i] = revisions[i*(len(revisions)-1)/(samples-1)]
+	}
+	return sampled
+}
+
+func analyzeRevision(g scm.Git, dir string, rev scm.Revision, env []string) (*Snapshot, error) {
+	tmp, err := ioutil.TempDir("", "gopack-history-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	if err := g.Export(dir, rev.Hash, tmp); err != nil {
+		return nil, err
+	}
+
+	snapshot := &Snapshot{Revision: rev, Deps: make(map[string]int), Checkouts: make(map[string]string)}
+	// revisions before gopack was set up have no dependencies
+	if _, err := os.Stat(filepath.Join(tmp, gopack.ConfigFile)); os.IsNotExist(err) {
+		return snapshot, nil
+	}
+	c, err := config.NewConfig(tmp)
+	if err != nil {
+		return nil, &RevisionError{rev, err}
+	}
+
+	// analyzed as gp stats would have at that revision
+	p, err := stats.Analyze(tmp, stats.Options{
+		Platforms:  c.Platforms,
+		Tags:       c.Tags,
+		Ignore:     c.Ignore,
+		Repository: c.Repository,
+		Managed:    c.DepImports(),
+		Env:        env,
+	})
+	if err != nil {
+		return nil, &RevisionError{rev, err}
+	}
+
+	for _, k := range c.DepKeys() {
+		dep, ok := c.DepsTree.Get(k + ".import").(string)
+		if !ok {
+			continue
+		}
+		snapshot.Checkouts[dep] = checkout(c, k)
+		snapshot.Deps[dep] = 0
+		for importPath, s := range p.ImportStatsByPath {
+			if importPath == dep || strings.HasPrefix(importPath, dep+"/") {
+				snapshot.Deps[dep] += len(s.ReferencePositions)
+			}
+		}
+	}
+	return snapshot, nil
+}
+
+// Branch, tag or commit of the [deps.key] table, as the type and the spec.
+func checkout(c *config.Config, key string) string {
+	for _, t := range []string{scm.Branch, scm.Tag, scm.Commit} {
+		if spec, ok := c.DepsTree.Get(key + "." + t).(string); ok {
+			return t + " " + spec
+		}
+	}
+	return ""
+}
+
+// Dependencies added and removed at the snapshot, compared to the previous one.
+func (t *Timeline) Changes(i int) (added, removed []string) {
+	previous := map[string]int{}
+	if i > 0 {
+		previous = t.Snapshots[i-1].Deps
+	}
+	current := t.Snapshots[i].Deps
+
+	for dep := range current {
+		if _, found := previous[dep]; !found {
+			added = append(added, dep)
+		}
+	}
+	for dep := range previous {
+		if _, found := current[dep]; !found {
+			removed = append(removed, dep)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return
+}
+
+// Dependencies kept at the snapshot whose imports changed, sorted.
+func (t *Timeline) Changed(i int) []string {
+	changed := []string{}
+	if i == 0 {
+		return changed
+	}
+	previous := t.Snapshots[i-1].Deps
+	for dep, n := range t.Snapshots[i].Deps {
+		if before, found := previous[dep]; found && before != n {
+			changed = append(changed, dep)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// Dependencies kept at the snapshot checked out at another
+// branch, tag or commit than in the previous one, sorted.
+func (t *Timeline) Repinned(i int) []string {
+	repinned := []string{}
+	if i == 0 {
+		return repinned
+	}
+	previous := t.Snapshots[i-1]
+	for dep, checkout := range t.Snapshots[i].Checkouts {
+		if _, found := previous.Deps[dep]; found && previous.Checkouts[dep] != checkout {
+			repinned = append(repinned, dep)
+		}
+	}
+	sort.Strings(repinned)
+	return repinned
+}
+
+// How a checkout reads in the history, the default branch included.
+func checkoutName(checkout string) string {
+	if checkout == "" {
+		return "default branch"
+	}
+	return checkout
+}
+
+func (t *Timeline) Print(w io.Writer) {
+	writer := tabwriter.NewWriter(w, 0, 8, 1, '\t', 0)
+
+	fmt.Fprint(writer, "Dependency history:\n\n")
+	for i, s := range t.Snapshots {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%d deps\n", s.Revision.Time.Format("2006-01-02"), s.Revision.Short(), s.Revision.Subject, len(s.Deps))
+
+		added, removed := t.Changes(i)
+		for _, dep := range added {
+			fmt.Fprintf(writer, "  + %s\t\t\t%d imports\n", dep, s.Deps[dep])
+		}
+		for _, dep := range removed {
+			fmt.Fprintf(writer, "  - %s\n", dep)
+		}
+		for _, dep := range t.Changed(i) {
+			fmt.Fprintf(writer, "  ~ %s\t\t\t%d imports\n", dep, s.Deps[dep])
+		}
+		for _, dep := range t.Repinned(i) {
+			fmt.Fprintf(writer, "  @ %s\t\t\t%s, was %s\n", dep, checkoutName(s.Checkouts[dep]), checkoutName(t.Snapshots[i-1].Checkouts[dep]))
+		}
+	}
+	writer.Flush()
+}
+
+// Write a row for every dependency at every snapshot,
+// along with the dependencies removed at it.
+func (t *Timeline) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"revision", "date", "dependency", "change", "imports", "checkout"})
+
+	for i, s := range t.Snapshots {
+		added, removed := t.Changes(i)
+		changes := make(map[string]string)
+		for _, dep := range t.Repinned(i) {
+			changes[dep] = "repinned"
+		}
+		for _, dep := range added {
+			changes[dep] = "added"
+		}
+
+		deps := make([]string, 0, len(s.Deps))
+		for dep := range s.Deps {
+			deps = append(deps, dep)
+		}
+		sort.Strings(deps)
+
+		date := s.Revision.Time.Format("2006-01-02")
+		for _, dep := range deps {
+			writer.Write([]string{s.Revision.Hash, date, dep, changes[dep], strconv.Itoa(s.Deps[dep]), s.Checkouts[dep]})
+		}
+		for _, dep := range removed {
+			writer.Write([]string{s.Revision.Hash, date, dep, "removed", "0", t.Snapshots[i-1].Checkouts[dep]})
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
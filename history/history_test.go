@@ -0,0 +1,248 @@
+package history
+
+import (
+	"bytes"
+	"github.com/d2fn/gopack"
+	"io/ioutil"
+	"os/exec"
+	"path"
+	"strings"
+	"testing"
+)
+
+func check(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
+
+func git(t *testing.T, dir string, args ...string) {
+	args = append([]string{"-c", "user.name=gopack", "-c", "user.email=gopack@example.com"}, args...)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %s failed: %s\n%s", args, err, out)
+	}
+}
+
+func commit(t *testing.T, dir, message string, files map[string]string) {
+	for name, content := range files {
+		check(ioutil.WriteFile(path.Join(dir, name), []byte(content), 0644))
+	}
+	git(t, dir, "add", "-A")
+	git(t, dir, "commit", "-q", "-m", message)
+}
+
+func setupTestRepo(t *testing.T) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "gopack-history-")
+	check(err)
+	git(t, dir, "init", "-q")
+
+	commit(t, dir, "Use mux", map[string]string{
+		gopack.ConfigFile: `
+[deps.mux]
+  import = "github.com/gorilla/mux"
+  tag = "v1.0.0"
+`,
+		"main.go": `package main
+import "github.com/gorilla/mux"
+`,
+	})
+
+	commit(t, dir, "Add toml", map[string]string{
+		gopack.ConfigFile: `
+[deps.mux]
+  import = "github.com/gorilla/mux"
+  tag = "v1.0.0"
+[deps.toml]
+  import = "github.com/pelletier/go-toml"
+  tag = "v0.1.0"
+`,
+		"config.go": `package main
+import "github.com/pelletier/go-toml"
+import "github.com/gorilla/mux"
+`,
+	})
+
+	commit(t, dir, "Drop mux", map[string]string{
+		gopack.ConfigFile: `
+[deps.toml]
+  import = "github.com/pelletier/go-toml"
+  tag = "v0.1.0"
+`,
+		"main.go": `package main
+`,
+		"config.go": `package main
+import "github.com/pelletier/go-toml"
+`,
+	})
+	return dir
+}
+
+func TestAnalyze(t *testing.T) {
+	dir := setupTestRepo(t)
+
+	timeline, err := Analyze(dir, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(timeline.Snapshots) != 3 {
+		t.Fatalf("Expected 3 snapshots but found %d\n", len(timeline.Snapshots))
+	}
+
+	if n := timeline.Snapshots[1].Deps["github.com/gorilla/mux"]; n != 2 {
+		t.Errorf("Expected mux to be imported twice in the second revision, but it was %d\n", n)
+	}
+
+	added, removed := timeline.Changes(1)
+	if len(added) != 1 || added[0] != "github.com/pelletier/go-toml" || len(removed) != 0 {
+		t.Errorf("Expected toml to be added in the second revision, but it was %v %v\n", added, removed)
+	}
+
+	added, removed = timeline.Changes(2)
+	if len(added) != 0 || len(removed) != 1 || removed[0] != "github.com/gorilla/mux" {
+		t.Errorf("Expected mux to be removed in the last revision, but it was %v %v\n", added, removed)
+	}
+
+	var out bytes.Buffer
+	check(timeline.WriteCSV(&out))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 6 || !strings.HasSuffix(lines[5], ",github.com/gorilla/mux,removed,0,tag v1.0.0") {
+		t.Errorf("Expected a CSV row per dependency and revision, but it was\n%s", out.String())
+	}
+}
+
+func TestSample(t *testing.T) {
+	dir := setupTestRepo(t)
+
+	timeline, err := Analyze(dir, 2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(timeline.Snapshots) != 2 {
+		t.Fatalf("Expected 2 snapshots but found %d\n", len(timeline.Snapshots))
+	}
+	if timeline.Snapshots[0].Revision.Subject != "Use mux" || timeline.Snapshots[1].Revision.Subject != "Drop mux" {
+		t.Errorf("Expected to sample the first and the last revisions\n")
+	}
+}
+
+func TestAnalyzeWithRevisionOptions(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir, err := ioutil.TempDir("", "gopack-history-")
+	check(err)
+	git(t, dir, "init", "-q")
+
+	commit(t, dir, "Use mux", map[string]string{
+		gopack.ConfigFile: `
+platforms = ["linux/amd64"]
+ignore = ["*_gen.go"]
+[deps.mux]
+  import = "github.com/gorilla/mux"
+  tag = "v1.0.0"
+`,
+		"main.go": `package main
+import "github.com/gorilla/mux"
+`,
+		"routes_gen.go": `package main
+import "github.com/gorilla/mux"
+`,
+		"main_windows.go": `package main
+import "github.com/gorilla/mux"
+`,
+	})
+
+	timeline, err := Analyze(dir, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := timeline.Snapshots[0].Deps["github.com/gorilla/mux"]; n != 1 {
+		t.Errorf("Expected to leave out ignored files and other platforms, but mux was imported %d times\n", n)
+	}
+}
+
+func TestAnalyzeSkipsBadRevisions(t *testing.T) {
+	dir := setupTestRepo(t)
+
+	commit(t, dir, "Break the config", map[string]string{
+		gopack.ConfigFile: `
+[deps.toml]
+  import = "github.com/pelletier/go-toml"
+  tga = "v0.1.0"
+`,
+	})
+	commit(t, dir, "Break the source", map[string]string{
+		gopack.ConfigFile: `
+[deps.toml]
+  import = "github.com/pelletier/go-toml"
+  tag = "v0.1.0"
+`,
+		"main.go": `package main
+import (
+`,
+	})
+
+	timeline, err := Analyze(dir, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(timeline.Snapshots) != 3 {
+		t.Errorf("Expected the 3 readable revisions to be analyzed but found %d\n", len(timeline.Snapshots))
+	}
+	if len(timeline.Skipped) != 2 || timeline.Skipped[0].Revision.Subject != "Break the config" || timeline.Skipped[1].Revision.Subject != "Break the source" {
+		t.Errorf("Expected the broken revisions to be skipped but it was %v\n", timeline.Skipped)
+	}
+}
+
+func TestRepinned(t *testing.T) {
+	dir := setupTestRepo(t)
+
+	commit(t, dir, "Upgrade toml", map[string]string{
+		gopack.ConfigFile: `
+[deps.toml]
+  import = "github.com/pelletier/go-toml"
+  tag = "v0.2.0"
+`,
+	})
+	commit(t, dir, "Follow toml master", map[string]string{
+		gopack.ConfigFile: `
+[deps.toml]
+  import = "github.com/pelletier/go-toml"
+`,
+	})
+
+	timeline, err := Analyze(dir, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if checkout := timeline.Snapshots[3].Checkouts["github.com/pelletier/go-toml"]; checkout != "tag v0.2.0" {
+		t.Errorf("Expected toml to be checked out at tag v0.2.0 but it was %q\n", checkout)
+	}
+	for i, expected := range [][]string{{}, {}, {}, {"github.com/pelletier/go-toml"}, {"github.com/pelletier/go-toml"}} {
+		if repinned := timeline.Repinned(i); strings.Join(repinned, ",") != strings.Join(expected, ",") {
+			t.Errorf("Expected %v to be repinned at revision %d but it was %v\n", expected, i, repinned)
+		}
+	}
+
+	var out bytes.Buffer
+	timeline.Print(&out)
+	if !strings.Contains(out.String(), "default branch, was tag v0.2.0") {
+		t.Errorf("Expected the history to show toml following its default branch but it was\n%s", out.String())
+	}
+
+	out.Reset()
+	check(timeline.WriteCSV(&out))
+	if !strings.Contains(out.String(), ",github.com/pelletier/go-toml,repinned,1,tag v0.2.0\n") {
+		t.Errorf("Expected a CSV row for the upgrade of toml but it was\n%s", out.String())
+	}
+}
@@ -0,0 +1,103 @@
+package manifests
+
+import (
+	"regexp"
+	"strings"
+)
+
+var glideSections = map[string]bool{
+	"import":      true,
+	"testImport":  true,
+	"imports":     true,
+	"testImports": true,
+}
+
+var (
+	glideKey   = regexp.MustCompile(`^(-\s+)?([A-Za-z]+):\s*(.*)$`)
+	glideRange = regexp.MustCompile(`[\^~<>*|, ]|\.x`)
+)
+
+// glide.yaml and glide.lock, read line by line as their layout is fixed:
+// lists of packages under import and testImport, imports and testImports
+// in the lock, which names them and pins their commit.
+func parseGlide(dat []byte, m *Manifest) error {
+	section := ""
+	var pkg map[string]string
+	flush := func() {
+		if pkg != nil && pkg["package"] == "" {
+			pkg["package"] = pkg["name"]
+		}
+		if pkg != nil && pkg["package"] != "" {
+			m.addGlidePackage(pkg, strings.HasPrefix(section, "testImport"))
+		}
+		pkg = nil
+	}
+
+	for _, line := range strings.Split(string(dat), "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		// glide writes the items of its lists unindented
+		nested := strings.ContainsRune(" \t-", rune(line[0]))
+		match := glideKey.FindStringSubmatch(strings.TrimSpace(line))
+		if !nested {
+			flush()
+			if match != nil {
+				section = match[2]
+				if section == "package" {
+					m.Repository = unquoteYAML(match[3])
+				}
+			}
+			continue
+		}
+		if match == nil || !glideSections[section] {
+			continue
+		}
+
+		// every package starts a new item of the list
+		if match[1] != "" {
+			flush()
+			pkg = make(map[string]string)
+		}
+		if pkg != nil {
+			pkg[match[2]] = unquoteYAML(match[3])
+		}
+	}
+	flush()
+	return nil
+}
+
+func (m *Manifest) addGlidePackage(pkg map[string]string, test bool) {
+	name := pkg["package"]
+	if pkg["repo"] != "" {
+		m.problem("%s is fetched from %s, which can't be expressed", name, pkg["repo"])
+	}
+
+	v := pkg["version"]
+	if v == "" {
+		m.add(Entry{Import: name, Test: test})
+		return
+	}
+	if glideRange.MatchString(v) {
+		m.problem("%s version %s is a range, which can't be expressed", name, v)
+		m.add(Entry{Import: name, Test: test})
+		return
+	}
+
+	e := guess(v)
+	e.Import = name
+	e.Test = test
+	m.add(e)
+}
+
+func unquoteYAML(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
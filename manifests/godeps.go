@@ -0,0 +1,29 @@
+package manifests
+
+import (
+	"encoding/json"
+	"github.com/d2fn/gopack/scm"
+)
+
+// Godeps/Godeps.json, written by godep.
+type godeps struct {
+	ImportPath string
+	Deps       []struct {
+		ImportPath string
+		Comment    string
+		Rev        string
+	}
+}
+
+func parseGodeps(dat []byte, m *Manifest) error {
+	g := godeps{}
+	if err := json.Unmarshal(dat, &g); err != nil {
+		return err
+	}
+
+	m.Repository = g.ImportPath
+	for _, d := range g.Deps {
+		m.add(Entry{Import: d.ImportPath, Kind: scm.Commit, Spec: d.Rev})
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package manifests
+
+import (
+	"fmt"
+	"github.com/d2fn/gopack/scm"
+	"regexp"
+	"strings"
+)
+
+var (
+	pseudoVersion = regexp.MustCompile(`^v[0-9]+\.[0-9]+\.[0-9]+-(?:[0-9A-Za-z.]+\.)?[0-9]{14}-([0-9a-f]{12})(\+incompatible)?$`)
+	majorPath     = regexp.MustCompile(`/v[0-9]+$`)
+)
+
+// go.mod, only its requirements are translated.
+func parseGoMod(dat []byte, m *Manifest) error {
+	block := ""
+	for n, line := range strings.Split(string(dat), "\n") {
+		comment := ""
+		if i := strings.Index(line, "//"); i >= 0 {
+			comment = strings.TrimSpace(line[i+2:])
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if block != "" {
+			if fields[0] == ")" {
+				block = ""
+				continue
+			}
+			fields = append([]string{block}, fields...)
+		} else if len(fields) == 2 && fields[1] == "(" {
+			block = fields[0]
+			continue
+		}
+
+		switch fields[0] {
+		case "module":
+			if len(fields) > 1 {
+				m.Repository = strings.Trim(fields[1], `"`)
+			}
+		case "require":
+			if len(fields) != 3 {
+				return fmt.Errorf("line %d: invalid require", n+1)
+			}
+			m.requireModule(strings.Trim(fields[1], `"`), fields[2], comment == "indirect")
+		case "replace", "exclude", "retract":
+			m.problem("%s in go.mod at line %d can't be expressed", fields[0], n+1)
+		}
+	}
+	return nil
+}
+
+func (m *Manifest) requireModule(path, v string, indirect bool) {
+	if indirect {
+		m.problem("%s is an indirect requirement, declare it in the gopack.config of the dependency needing it", path)
+		return
+	}
+	if majorPath.MatchString(path) {
+		m.problem("%s can't be fetched without modules, its major version is part of the import path", path)
+		return
+	}
+
+	if match := pseudoVersion.FindStringSubmatch(v); match != nil {
+		m.add(Entry{Import: path, Kind: scm.Commit, Spec: match[1]})
+		return
+	}
+	m.add(Entry{Import: path, Kind: scm.Tag, Spec: strings.TrimSuffix(v, "+incompatible")})
+}
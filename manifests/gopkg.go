@@ -0,0 +1,80 @@
+package manifests
+
+import (
+	"github.com/d2fn/gopack/scm"
+	"strconv"
+	"strings"
+)
+
+// Gopkg.toml and Gopkg.lock, written by dep. Their arrays of tables are
+// read line by line, as the toml parser gopack uses doesn't support them.
+func parseGopkg(dat []byte, m *Manifest) error {
+	lock := m.File == "Gopkg.lock"
+	var project map[string]string
+	flush := func() {
+		if project != nil && project["name"] != "" {
+			m.addGopkgProject(project, lock)
+		}
+		project = nil
+	}
+
+	for _, line := range strings.Split(string(dat), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			flush()
+			table := strings.Trim(line, "[] ")
+			if table == "constraint" || table == "override" || table == "projects" {
+				project = make(map[string]string)
+			}
+			continue
+		}
+
+		eq := strings.Index(line, "=")
+		if project == nil || eq < 0 {
+			continue
+		}
+		key := strings.TrimSpace(line[:eq])
+		// arrays, like the packages of the lock, aren't needed
+		if value, err := strconv.Unquote(strings.TrimSpace(line[eq+1:])); err == nil {
+			project[key] = value
+		}
+	}
+	flush()
+	return nil
+}
+
+func (m *Manifest) addGopkgProject(p map[string]string, lock bool) {
+	name := p["name"]
+	if p["source"] != "" {
+		m.problem("%s is fetched from %s, which can't be expressed", name, p["source"])
+	}
+
+	switch {
+	case p["version"] != "":
+		v := strings.TrimPrefix(p["version"], "=")
+		if !version.MatchString(v) {
+			m.problem("%s version %s is a range, which can't be expressed", name, p["version"])
+			m.add(Entry{Import: name})
+			return
+		}
+		// the lock records the tag it found, but dep reads a bare version
+		// in Gopkg.toml as a caret range, narrowed here to its lowest tag
+		if !lock && !strings.HasPrefix(p["version"], "=") {
+			m.problem("%s version %s is the range ^%s, pinned to the tag %s", name, v, v, v)
+		}
+		m.add(Entry{Import: name, Kind: scm.Tag, Spec: v})
+	case lock && p["revision"] != "":
+		// the lock pins branches to the revision it found
+		m.add(Entry{Import: name, Kind: scm.Commit, Spec: p["revision"]})
+	case p["branch"] != "":
+		m.add(Entry{Import: name, Kind: scm.Branch, Spec: p["branch"]})
+	case p["revision"] != "":
+		m.add(Entry{Import: name, Kind: scm.Commit, Spec: p["revision"]})
+	default:
+		m.add(Entry{Import: name})
+	}
+}
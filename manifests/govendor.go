@@ -0,0 +1,37 @@
+package manifests
+
+import (
+	"encoding/json"
+	"github.com/d2fn/gopack/scm"
+)
+
+// vendor/vendor.json, written by govendor.
+type govendor struct {
+	RootPath string
+	Package  []struct {
+		Path         string
+		Revision     string
+		Version      string
+		VersionExact string
+	}
+}
+
+func parseGovendor(dat []byte, m *Manifest) error {
+	g := govendor{}
+	if err := json.Unmarshal(dat, &g); err != nil {
+		return err
+	}
+
+	m.Repository = g.RootPath
+	for _, p := range g.Package {
+		switch {
+		case p.VersionExact != "":
+			m.add(Entry{Import: p.Path, Kind: scm.Tag, Spec: p.VersionExact})
+		case p.Revision != "":
+			m.add(Entry{Import: p.Path, Kind: scm.Commit, Spec: p.Revision})
+		default:
+			m.add(Entry{Import: p.Path})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+// Package manifests reads the files other dependency managers
+// declare dependencies in, to translate them to gopack.config.
+package manifests
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/d2fn/gopack/scm"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// A dependency declared by another manager.
+type Entry struct {
+	Import string
+	// scm.Branch, scm.Commit or scm.Tag, empty when it floats.
+	Kind string
+	Spec string
+	// Only needed by tests.
+	Test bool
+}
+
+type Manifest struct {
+	// Name of the file the manifest was read from.
+	File string
+	// Import path of the project, if the manifest declares it.
+	Repository string
+	Entries    []Entry
+	// What couldn't be translated, one message each.
+	Problems []string
+}
+
+// Files known to hold a manifest, in the order they are looked for.
+var parsers = []struct {
+	name  string
+	parse func(dat []byte, m *Manifest) error
+}{
+	{"Gopkg.lock", parseGopkg},
+	{"Gopkg.toml", parseGopkg},
+	{"glide.lock", parseGlide},
+	{"glide.yaml", parseGlide},
+	{"Godeps.json", parseGodeps},
+	{"vendor.json", parseGovendor},
+	{"go.mod", parseGoMod},
+}
+
+// Read the manifest at path, telling its format by its file name.
+func Parse(path string) (*Manifest, error) {
+	name := filepath.Base(path)
+	for _, p := range parsers {
+		if p.name != name {
+			continue
+		}
+
+		dat, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		m := &Manifest{File: name}
+		if err := p.parse(dat, m); err != nil {
+			return nil, fmt.Errorf("%s: %s", path, err)
+		}
+		m.collapse()
+		return m, nil
+	}
+	return nil, fmt.Errorf("%s is not a known manifest, expected one of %s", path, strings.Join(Names(), ", "))
+}
+
+// File names of the manifests known.
+func Names() []string {
+	names := make([]string, len(parsers))
+	for i, p := range parsers {
+		names[i] = p.name
+	}
+	return names
+}
+
+// Find the manifest of the project in dir, if there's one.
+// Godeps.json and vendor.json are looked for where their managers keep them.
+func Find(dir string) (string, bool) {
+	for _, p := range parsers {
+		for _, sub := range []string{"", "Godeps", "vendor"} {
+			path := filepath.Join(dir, sub, p.name)
+			if isFile(path) {
+				return path, true
+			}
+		}
+	}
+	return "", false
+}
+
+func (m *Manifest) add(e Entry) {
+	m.Entries = append(m.Entries, e)
+}
+
+func (m *Manifest) problem(format string, args ...interface{}) {
+	m.Problems = append(m.Problems, fmt.Sprintf(format, args...))
+}
+
+// Merge the entries of the packages of the same repository,
+// which Godeps and govendor list one by one.
+func (m *Manifest) collapse() {
+	byRoot := make(map[string]int)
+	entries := []Entry{}
+	for _, e := range m.Entries {
+		root := RepoRoot(e.Import)
+		i, found := byRoot[root]
+		if !found {
+			byRoot[root] = len(entries)
+			e.Import = root
+			entries = append(entries, e)
+			continue
+		}
+		if entries[i].Kind != e.Kind || entries[i].Spec != e.Spec {
+			m.problem("%s is at different revisions in %s, using %s", root, m.File, describe(entries[i]))
+		}
+		entries[i].Test = entries[i].Test && e.Test
+	}
+	m.Entries = entries
+}
+
+var hostRoots = map[string]int{
+	"github.com":    3,
+	"bitbucket.org": 3,
+	"gitlab.com":    3,
+	"golang.org":    3,
+	"gopkg.in":      3,
+}
+
+var gopkgVersion = regexp.MustCompile(`\.v[0-9]+$`)
+
+// Import path of the repository holding the package,
+// for the hosts whose layout is known.
+func RepoRoot(importPath string) string {
+	parts := strings.Split(importPath, "/")
+	n, known := hostRoots[parts[0]]
+	if !known {
+		return importPath
+	}
+	// gopkg.in/yaml.v2 rather than gopkg.in/user/pkg.v2
+	if parts[0] == "gopkg.in" && len(parts) > 1 && gopkgVersion.MatchString(parts[1]) {
+		n = 2
+	}
+	if len(parts) < n {
+		return importPath
+	}
+	return strings.Join(parts[:n], "/")
+}
+
+func describe(e Entry) string {
+	if e.Kind == "" {
+		return "its default branch"
+	}
+	return e.Kind + " " + e.Spec
+}
+
+var (
+	commitHash = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+	version    = regexp.MustCompile(`^v?[0-9]+(\.[0-9]+)*([-+][0-9A-Za-z.-]+)?$`)
+)
+
+// Tell what a version some managers don't qualify is: a commit,
+// a tag when it looks like a version, a branch otherwise.
+func guess(spec string) Entry {
+	switch {
+	case commitHash.MatchString(spec):
+		return Entry{Kind: scm.Commit, Spec: spec}
+	case version.MatchString(spec):
+		return Entry{Kind: scm.Tag, Spec: spec}
+	}
+	return Entry{Kind: scm.Branch, Spec: spec}
+}
+
+// Write the manifest as a gopack.config.
+func (m *Manifest) Config() []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Imported from %s\n", m.File)
+	if m.Repository != "" {
+		fmt.Fprintf(&buf, "repo = %q\n", m.Repository)
+	}
+
+	entries := append([]Entry{}, m.Entries...)
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Import < entries[j].Import })
+
+	keys := make(map[string]bool)
+	for _, e := range entries {
+		key := depKey(e.Import, keys)
+		keys[key] = true
+
+		fmt.Fprintf(&buf, "\n[deps.%s]\n", key)
+		fmt.Fprintf(&buf, "  import = %q\n", e.Import)
+		if e.Kind != "" {
+			fmt.Fprintf(&buf, "  %s = %q\n", e.Kind, e.Spec)
+		}
+		if e.Test {
+			fmt.Fprint(&buf, "  scope = \"test\"\n")
+		}
+	}
+	return buf.Bytes()
+}
+
+var nonKey = regexp.MustCompile(`[^a-z_]+`)
+
+// Name the table of the dependency after the last elements of its
+// import path, as keys can only hold letters and underscores.
+func depKey(importPath string, taken map[string]bool) string {
+	parts := strings.Split(strings.ToLower(importPath), "/")
+	key := ""
+	for i := len(parts) - 1; i >= 0; i-- {
+		name := strings.Trim(nonKey.ReplaceAllString(parts[i], "_"), "_")
+		if name == "" {
+			continue
+		}
+		if key == "" {
+			key = name
+		} else {
+			key = name + "_" + key
+		}
+		if !taken[key] {
+			return key
+		}
+	}
+
+	if key == "" {
+		key = "dep"
+	}
+	for taken[key] {
+		key += "_"
+	}
+	return key
+}
+
+func isFile(path string) bool {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+
+	return stat.Mode().IsRegular()
+}
@@ -0,0 +1,309 @@
+package manifests
+
+import (
+	"github.com/d2fn/gopack/scm"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func parseFixture(t *testing.T, name, content string) *Manifest {
+	dir, err := ioutil.TempDir("", "gopack-manifests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m, err := Parse(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func checkEntries(t *testing.T, m *Manifest, expected []Entry) {
+	if len(m.Entries) != len(expected) {
+		t.Fatalf("Expected %d entries in %s but found %v\n", len(expected), m.File, m.Entries)
+	}
+	for i, e := range expected {
+		if m.Entries[i] != e {
+			t.Errorf("Expected %v in %s but found %v\n", e, m.File, m.Entries[i])
+		}
+	}
+}
+
+func checkProblems(t *testing.T, m *Manifest, expected ...string) {
+	if len(m.Problems) != len(expected) {
+		t.Fatalf("Expected %d problems in %s but found %v\n", len(expected), m.File, m.Problems)
+	}
+	for i, p := range expected {
+		if !strings.Contains(m.Problems[i], p) {
+			t.Errorf("Expected the problem %q to mention %s\n", m.Problems[i], p)
+		}
+	}
+}
+
+func TestParseGodeps(t *testing.T) {
+	m := parseFixture(t, "Godeps.json", `{
+	"ImportPath": "github.com/d2fn/app",
+	"Deps": [
+		{"ImportPath": "github.com/gorilla/mux", "Rev": "9c068cf16d982f8bd444b8c352acbeec34c4fe5b"},
+		{"ImportPath": "golang.org/x/net/context", "Rev": "a6577fac2d73be281a500b310739095313165611"},
+		{"ImportPath": "golang.org/x/net/html", "Rev": "a6577fac2d73be281a500b310739095313165611"},
+		{"ImportPath": "golang.org/x/net/http2", "Rev": "f2499483f923065a842d38eb4c7f1927e6fc6e6d"}
+	]
+}`)
+
+	if m.Repository != "github.com/d2fn/app" {
+		t.Errorf("Expected the repository to be github.com/d2fn/app but it was %s\n", m.Repository)
+	}
+	checkEntries(t, m, []Entry{
+		{Import: "github.com/gorilla/mux", Kind: scm.Commit, Spec: "9c068cf16d982f8bd444b8c352acbeec34c4fe5b"},
+		{Import: "golang.org/x/net", Kind: scm.Commit, Spec: "a6577fac2d73be281a500b310739095313165611"},
+	})
+	checkProblems(t, m, "golang.org/x/net is at different revisions")
+}
+
+func TestParseGlide(t *testing.T) {
+	m := parseFixture(t, "glide.yaml", `package: github.com/d2fn/app
+import:
+- package: github.com/gorilla/mux
+  version: v1.6.2
+- package: gopkg.in/yaml.v2
+  version: "5420a8b6744d3b0345ab293f6fcba19c978f1183"
+  subpackages:
+  - internal
+- package: github.com/pelletier/go-toml
+  version: ^1.2.0
+- package: github.com/spf13/cobra
+  version: develop
+  repo: git@github.com:d2fn/cobra.git
+testImport:
+- package: github.com/stretchr/testify
+  subpackages:
+  - assert
+`)
+
+	if m.Repository != "github.com/d2fn/app" {
+		t.Errorf("Expected the repository to be github.com/d2fn/app but it was %s\n", m.Repository)
+	}
+	checkEntries(t, m, []Entry{
+		{Import: "github.com/gorilla/mux", Kind: scm.Tag, Spec: "v1.6.2"},
+		{Import: "gopkg.in/yaml.v2", Kind: scm.Commit, Spec: "5420a8b6744d3b0345ab293f6fcba19c978f1183"},
+		{Import: "github.com/pelletier/go-toml"},
+		{Import: "github.com/spf13/cobra", Kind: scm.Branch, Spec: "develop"},
+		{Import: "github.com/stretchr/testify", Test: true},
+	})
+	checkProblems(t, m, "^1.2.0 is a range", "fetched from git@github.com:d2fn/cobra.git")
+}
+
+func TestParseGlideLock(t *testing.T) {
+	m := parseFixture(t, "glide.lock", `hash: 0f56d1e4bd3e1eb6d0b8ef1a8f8c3ffb3dcf4e5fa0a6b8f5a3c2e8b1c6a9d4e7
+updated: 2018-06-12T10:32:27.351216+02:00
+imports:
+- name: github.com/gorilla/mux
+  version: e3702bed27f0d39777b0b37b664b6280e8ef8fbf
+- name: golang.org/x/net
+  version: a6577fac2d73be281a500b310739095313165611
+  subpackages:
+  - context
+testImports:
+- name: github.com/stretchr/testify
+  version: f35b8ab0b5a2cef36673838d662e249dd9c94686
+`)
+
+	checkEntries(t, m, []Entry{
+		{Import: "github.com/gorilla/mux", Kind: scm.Commit, Spec: "e3702bed27f0d39777b0b37b664b6280e8ef8fbf"},
+		{Import: "golang.org/x/net", Kind: scm.Commit, Spec: "a6577fac2d73be281a500b310739095313165611"},
+		{Import: "github.com/stretchr/testify", Kind: scm.Commit, Spec: "f35b8ab0b5a2cef36673838d662e249dd9c94686", Test: true},
+	})
+	checkProblems(t, m)
+}
+
+func TestParseGopkg(t *testing.T) {
+	m := parseFixture(t, "Gopkg.toml", `required = ["github.com/golang/mock/mockgen"]
+
+[[constraint]]
+  name = "github.com/gorilla/mux"
+  version = "=1.6.2"
+
+[[constraint]]
+  name = "github.com/pelletier/go-toml"
+  version = "~1.2.0"
+
+[[constraint]]
+  branch = "master"
+  name = "github.com/spf13/cobra"
+
+[[constraint]]
+  name = "github.com/sirupsen/logrus"
+  version = "1.2.0"
+
+[[override]]
+  name = "gopkg.in/yaml.v2"
+  revision = "5420a8b6744d3b0345ab293f6fcba19c978f1183"
+  source = "https://github.com/d2fn/yaml.git"
+
+[prune]
+  go-tests = true
+`)
+
+	checkEntries(t, m, []Entry{
+		{Import: "github.com/gorilla/mux", Kind: scm.Tag, Spec: "1.6.2"},
+		{Import: "github.com/pelletier/go-toml"},
+		{Import: "github.com/spf13/cobra", Kind: scm.Branch, Spec: "master"},
+		{Import: "github.com/sirupsen/logrus", Kind: scm.Tag, Spec: "1.2.0"},
+		{Import: "gopkg.in/yaml.v2", Kind: scm.Commit, Spec: "5420a8b6744d3b0345ab293f6fcba19c978f1183"},
+	})
+	checkProblems(t, m, "~1.2.0 is a range", "1.2.0 is the range ^1.2.0, pinned to the tag 1.2.0", "fetched from https://github.com/d2fn/yaml.git")
+}
+
+func TestParseGopkgLock(t *testing.T) {
+	m := parseFixture(t, "Gopkg.lock", `[[projects]]
+  digest = "1:ca59b1175189b3f0e9f1793d2c350114be36eaabbe5b9f554b35edee1de50aea"
+  name = "github.com/gorilla/mux"
+  packages = ["."]
+  revision = "e3702bed27f0d39777b0b37b664b6280e8ef8fbf"
+  version = "v1.6.2"
+
+[[projects]]
+  branch = "master"
+  name = "github.com/spf13/cobra"
+  packages = [
+    ".",
+    "doc",
+  ]
+  revision = "ef82de70bb3f60c65fb8eebacbb2d122ef517385"
+
+[solve-meta]
+  analyzer-name = "dep"
+`)
+
+	checkEntries(t, m, []Entry{
+		{Import: "github.com/gorilla/mux", Kind: scm.Tag, Spec: "v1.6.2"},
+		{Import: "github.com/spf13/cobra", Kind: scm.Commit, Spec: "ef82de70bb3f60c65fb8eebacbb2d122ef517385"},
+	})
+	checkProblems(t, m)
+}
+
+func TestParseGovendor(t *testing.T) {
+	m := parseFixture(t, "vendor.json", `{
+	"rootPath": "github.com/d2fn/app",
+	"package": [
+		{"path": "github.com/gorilla/mux", "revision": "e3702bed27f0d39777b0b37b664b6280e8ef8fbf", "version": "v1.6", "versionExact": "v1.6.2"},
+		{"path": "github.com/spf13/cobra", "revision": "ef82de70bb3f60c65fb8eebacbb2d122ef517385"},
+		{"path": "github.com/spf13/cobra/doc", "revision": "ef82de70bb3f60c65fb8eebacbb2d122ef517385"}
+	]
+}`)
+
+	checkEntries(t, m, []Entry{
+		{Import: "github.com/gorilla/mux", Kind: scm.Tag, Spec: "v1.6.2"},
+		{Import: "github.com/spf13/cobra", Kind: scm.Commit, Spec: "ef82de70bb3f60c65fb8eebacbb2d122ef517385"},
+	})
+	checkProblems(t, m)
+}
+
+func TestParseGoMod(t *testing.T) {
+	m := parseFixture(t, "go.mod", `module github.com/d2fn/app
+
+go 1.16
+
+require (
+	github.com/gorilla/mux v1.6.2
+	github.com/coreos/etcd v3.3.10+incompatible
+	github.com/spf13/cobra v0.0.0-20190321000552-67fc4837d267 // indirect
+	github.com/go-redis/redis/v8 v8.11.0
+)
+
+require golang.org/x/net v0.0.0-20190404232315-eb5bcb51f2a3
+
+replace github.com/gorilla/mux => ../mux
+`)
+
+	if m.Repository != "github.com/d2fn/app" {
+		t.Errorf("Expected the repository to be github.com/d2fn/app but it was %s\n", m.Repository)
+	}
+	checkEntries(t, m, []Entry{
+		{Import: "github.com/gorilla/mux", Kind: scm.Tag, Spec: "v1.6.2"},
+		{Import: "github.com/coreos/etcd", Kind: scm.Tag, Spec: "v3.3.10"},
+		{Import: "golang.org/x/net", Kind: scm.Commit, Spec: "eb5bcb51f2a3"},
+	})
+	checkProblems(t, m, "github.com/spf13/cobra", "github.com/go-redis/redis/v8", "replace")
+}
+
+func TestParseUnknown(t *testing.T) {
+	if _, err := Parse("Makefile"); err == nil {
+		t.Error("Expected Makefile not to be a known manifest")
+	}
+}
+
+func TestFind(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gopack-manifests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if _, found := Find(dir); found {
+		t.Error("Expected no manifest in an empty directory")
+	}
+
+	os.MkdirAll(filepath.Join(dir, "Godeps"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "Godeps", "Godeps.json"), []byte("{}"), 0644)
+	path, found := Find(dir)
+	if !found || path != filepath.Join(dir, "Godeps", "Godeps.json") {
+		t.Errorf("Expected to find Godeps/Godeps.json but found %s\n", path)
+	}
+}
+
+func TestConfig(t *testing.T) {
+	m := &Manifest{
+		File:       "glide.yaml",
+		Repository: "github.com/d2fn/app",
+		Entries: []Entry{
+			{Import: "gopkg.in/yaml.v2", Kind: scm.Tag, Spec: "v2.2.1"},
+			{Import: "github.com/stretchr/testify", Test: true},
+			{Import: "github.com/d2fn/yaml", Kind: scm.Branch, Spec: "master"},
+		},
+	}
+
+	expected := `# Imported from glide.yaml
+repo = "github.com/d2fn/app"
+
+[deps.yaml]
+  import = "github.com/d2fn/yaml"
+  branch = "master"
+
+[deps.testify]
+  import = "github.com/stretchr/testify"
+  scope = "test"
+
+[deps.yaml_v]
+  import = "gopkg.in/yaml.v2"
+  tag = "v2.2.1"
+`
+	if config := string(m.Config()); config != expected {
+		t.Errorf("Expected the config\n%s\nbut it was\n%s\n", expected, config)
+	}
+}
+
+func TestDepKey(t *testing.T) {
+	taken := map[string]bool{"mux": true}
+	expected := map[string]string{
+		"github.com/gorilla/mux":       "gorilla_mux",
+		"github.com/pelletier/go-toml": "go_toml",
+		"gopkg.in/yaml.v2":             "yaml_v",
+		"github.com/d2fn/3d":           "d",
+	}
+	for importPath, key := range expected {
+		if k := depKey(importPath, taken); k != key {
+			t.Errorf("Expected the key of %s to be %s but it was %s\n", importPath, key, k)
+		}
+	}
+}
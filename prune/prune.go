@@ -0,0 +1,215 @@
+// Package prune removes from the vendor tree the code
+// a project doesn't need to build.
+package prune
+
+import (
+	"github.com/d2fn/gopack"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type Options struct {
+	// Drop the _test.go files and testdata directories of the packages kept.
+	Tests bool
+	// Drop every file other than sources, licenses are always kept.
+	Assets bool
+}
+
+type Result struct {
+	// Files removed, relative to the vendor tree.
+	Removed []string
+	// Size of the files removed.
+	BytesSaved int64
+}
+
+// Files making up a package along with the Go ones.
+var sourceExts = map[string]bool{
+	".go": true, ".c": true, ".h": true, ".s": true, ".S": true, ".cc": true,
+	".cpp": true, ".cxx": true, ".hh": true, ".hpp": true, ".hxx": true,
+	".m": true, ".f": true, ".F": true, ".for": true, ".f90": true, ".syso": true,
+}
+
+var licensePrefixes = []string{"license", "licence", "copying", "notice", "patents", "authors", "unlicense"}
+
+// Version control metadata, kept so dependencies can still be updated.
+var scmDirs = map[string]bool{".git": true, ".hg": true, ".svn": true, ".bzr": true}
+
+// Prune the vendor tree of the project at root, keeping only the packages
+// reachable from imports, following the imports of the vendored code.
+func Prune(root string, imports []string, opts Options) (*Result, error) {
+	src := filepath.Join(root, gopack.VendorDir, "src")
+	keep, err := reachable(src, imports, !opts.Tests)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{}
+	err = filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == src {
+			return nil
+		}
+		// the project's own repository is linked in the vendor tree
+		if info.Mode()&os.ModeSymlink != 0 {
+			return nil
+		}
+		if info.IsDir() {
+			if scmDirs[info.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if !removable(filepath.ToSlash(filepath.Dir(rel)), info.Name(), keep, opts) {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		result.Removed = append(result.Removed, filepath.ToSlash(rel))
+		result.BytesSaved += info.Size()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, removeEmptyDirs(src)
+}
+
+// Whether to remove the file name of the directory pkg. Everything but
+// licenses goes with the packages that aren't reachable, the options
+// only apply to the packages kept.
+func removable(pkg, name string, keep map[string]bool, opts Options) bool {
+	if isLicense(name) {
+		return false
+	}
+	// testdata belongs to the package above it
+	owner, inTestdata := pkg, false
+	if i := strings.Index("/"+pkg+"/", "/testdata/"); i >= 0 {
+		owner, inTestdata = strings.TrimSuffix(pkg[:i], "/"), true
+	}
+	if !keep[owner] {
+		return true
+	}
+
+	if inTestdata || strings.HasSuffix(name, "_test.go") {
+		return opts.Tests
+	}
+	if sourceExts[filepath.Ext(name)] {
+		return false
+	}
+	return opts.Assets
+}
+
+func isLicense(name string) bool {
+	name = strings.ToLower(name)
+	for _, prefix := range licensePrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Import paths of the vendored packages reachable from imports.
+func reachable(src string, imports []string, tests bool) (map[string]bool, error) {
+	keep := make(map[string]bool)
+	queue := append([]string{}, imports...)
+	for len(queue) > 0 {
+		importPath := queue[0]
+		queue = queue[1:]
+		if keep[importPath] {
+			continue
+		}
+
+		found, err := packageImports(filepath.Join(src, filepath.FromSlash(importPath)), tests)
+		if err != nil {
+			return nil, err
+		}
+		// standard library or not vendored
+		if found == nil {
+			continue
+		}
+		keep[importPath] = true
+		queue = append(queue, found...)
+	}
+	return keep, nil
+}
+
+// Imports of the package in dir, nil if there is no package there.
+func packageImports(dir string, tests bool) ([]string, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, nil
+	}
+
+	var imports []string
+	for _, f := range files {
+		name := f.Name()
+		if f.IsDir() || !strings.HasSuffix(name, ".go") || (!tests && strings.HasSuffix(name, "_test.go")) {
+			continue
+		}
+		if imports == nil {
+			imports = []string{}
+		}
+
+		parsed, err := parser.ParseFile(token.NewFileSet(), filepath.Join(dir, name), nil, parser.ImportsOnly)
+		if err != nil {
+			return nil, err
+		}
+		for _, i := range parsed.Imports {
+			importPath, err := strconv.Unquote(i.Path.Value)
+			if err != nil {
+				return nil, err
+			}
+			imports = append(imports, importPath)
+		}
+	}
+	return imports, nil
+}
+
+// Remove the directories left empty, deepest first.
+func removeEmptyDirs(src string) error {
+	dirs := []string{}
+	err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if scmDirs[info.Name()] {
+				return filepath.SkipDir
+			}
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Sort(sort.Reverse(sort.StringSlice(dirs)))
+	for _, dir := range dirs {
+		if dir == src {
+			continue
+		}
+		if entries, err := ioutil.ReadDir(dir); err == nil && len(entries) == 0 {
+			if err := os.Remove(dir); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package prune
+
+import (
+	"github.com/d2fn/gopack"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func check(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
+
+func createFile(src, name, content string) {
+	path := filepath.Join(src, filepath.FromSlash(name))
+	check(os.MkdirAll(filepath.Dir(path), 0755))
+	check(ioutil.WriteFile(path, []byte(content), 0644))
+}
+
+func setupTestVendor() (string, string) {
+	root, err := ioutil.TempDir("", "gopack-prune-")
+	check(err)
+	src := filepath.Join(root, gopack.VendorDir, "src")
+
+	createFile(src, "github.com/gorilla/mux/mux.go", "package mux\nimport \"github.com/gorilla/context\"\n")
+	createFile(src, "github.com/gorilla/mux/mux_test.go", "package mux\nimport \"github.com/stretchr/assert\"\n")
+	createFile(src, "github.com/gorilla/mux/LICENSE", "BSD")
+	createFile(src, "github.com/gorilla/mux/README.md", "mux")
+	createFile(src, "github.com/gorilla/mux/.git/config", "[core]")
+	createFile(src, "github.com/gorilla/mux/examples/main.go", "package main\nimport \"github.com/gorilla/mux\"\n")
+	createFile(src, "github.com/gorilla/mux/examples/data.json", "{}")
+	createFile(src, "github.com/gorilla/mux/unused/templates/a.tmpl", "{{.}}")
+	createFile(src, "github.com/gorilla/mux/testdata/routes.txt", "/")
+	createFile(src, "github.com/gorilla/context/context.go", "package context\nimport \"fmt\"\n")
+	createFile(src, "github.com/stretchr/assert/assert.go", "package assert\n")
+
+	check(os.MkdirAll(filepath.Join(src, "github.com/d2fn"), 0755))
+	check(os.Symlink(root, filepath.Join(src, "github.com/d2fn/gopack")))
+	return root, src
+}
+
+func checkRemoved(t *testing.T, result *Result, expected ...string) {
+	sort.Strings(result.Removed)
+	sort.Strings(expected)
+	if strings.Join(result.Removed, " ") != strings.Join(expected, " ") {
+		t.Errorf("Expected to remove %v but removed %v\n", expected, result.Removed)
+	}
+}
+
+func exists(src, name string) bool {
+	_, err := os.Lstat(filepath.Join(src, filepath.FromSlash(name)))
+	return err == nil
+}
+
+func TestPrune(t *testing.T) {
+	root, src := setupTestVendor()
+
+	result, err := Prune(root, []string{"fmt", "github.com/gorilla/mux"}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkRemoved(t, result,
+		"github.com/gorilla/mux/examples/main.go",
+		"github.com/gorilla/mux/examples/data.json",
+		"github.com/gorilla/mux/unused/templates/a.tmpl",
+	)
+	if result.BytesSaved != 52 {
+		t.Errorf("Expected to save 52 bytes but saved %d\n", result.BytesSaved)
+	}
+	if exists(src, "github.com/gorilla/mux/examples") || exists(src, "github.com/gorilla/mux/unused") {
+		t.Error("Expected to remove the directories left empty")
+	}
+	if !exists(src, "github.com/gorilla/mux/README.md") || !exists(src, "github.com/gorilla/mux/testdata/routes.txt") {
+		t.Error("Expected to keep the assets and the testdata of the packages kept")
+	}
+	if !exists(src, "github.com/d2fn/gopack") || !exists(src, "github.com/gorilla/mux/.git/config") {
+		t.Error("Expected to keep the project's repository and the scm metadata")
+	}
+}
+
+func TestPruneTestsAndAssets(t *testing.T) {
+	root, src := setupTestVendor()
+
+	result, err := Prune(root, []string{"github.com/gorilla/mux"}, Options{Tests: true, Assets: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkRemoved(t, result,
+		"github.com/gorilla/mux/examples/main.go",
+		"github.com/gorilla/mux/examples/data.json",
+		"github.com/gorilla/mux/unused/templates/a.tmpl",
+		"github.com/gorilla/mux/testdata/routes.txt",
+		"github.com/gorilla/mux/mux_test.go",
+		"github.com/gorilla/mux/README.md",
+		"github.com/stretchr/assert/assert.go",
+	)
+	if !exists(src, "github.com/gorilla/mux/LICENSE") || !exists(src, "github.com/gorilla/context/context.go") {
+		t.Error("Expected to keep licenses and the packages imported by vendored code")
+	}
+}
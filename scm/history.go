@@ -0,0 +1,111 @@
+package scm
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A commit of the repository.
+type Revision struct {
+	Hash    string
+	Time    time.Time
+	Subject string
+}
+
+func (r Revision) Short() string {
+	if len(r.Hash) > 7 {
+		return r.Hash[:7]
+	}
+	return r.Hash
+}
+
+// Revisions of the repository in dir touching it, oldest first.
+func (g Git) Revisions(dir string) ([]Revision, error) {
+	cmd := exec.Command("git", "log", "--reverse", "--format=%H%x00%ct%x00%s", "--", ".")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("error reading the history of %s: %s", dir, err)
+	}
+
+	revisions := []Revision{}
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.SplitN(line, "\x00", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		seconds, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, Revision{Hash: fields[0], Time: time.Unix(seconds, 0), Subject: fields[2]})
+	}
+	return revisions, nil
+}
+
+// Write the tree of dir at the revision to dest.
+func (g Git) Export(dir, rev, dest string) error {
+	cmd := exec.Command("git", "archive", "--format=tar", rev)
+	cmd.Dir = dir
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	if err := untar(out, dest); err != nil {
+		cmd.Wait()
+		return err
+	}
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("error exporting %s at %s: %s", dir, rev, err)
+	}
+	return nil
+}
+
+func untar(r io.Reader, dest string) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		path := filepath.Join(dest, filepath.FromSlash(hdr.Name))
+		if !strings.HasPrefix(path, filepath.Clean(dest)+string(filepath.Separator)) {
+			return fmt.Errorf("%s is out of %s", hdr.Name, dest)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(path, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return err
+			}
+			f, err := os.Create(path)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(f, tr)
+			f.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,160 @@
+// Package scm checks out dependencies with the
+// version control system they are hosted in.
+package scm
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kinds of checkout a dependency can point at.
+const (
+	Branch = "branch"
+	Commit = "commit"
+	Tag    = "tag"
+)
+
+type Scm interface {
+	// Checkout points the working copy in dir at the branch, commit or tag spec.
+	Checkout(dir, kind, spec string) error
+	// Revision the working copy in dir is at.
+	Revision(dir string) (string, error)
+	// Time the revision the working copy in dir is at was committed.
+	Time(dir string) (time.Time, error)
+}
+
+type Git struct {
+}
+
+type Hg struct {
+}
+
+type Svn struct {
+}
+
+var scms = map[string]Scm{".git": Git{}, ".hg": Hg{}, ".svn": Svn{}}
+
+// Detect tells which scm hosts the code in dir.
+// It traverses the tree backwards up to levels directories,
+// looking for the scm metadata directory.
+func Detect(dir string, levels int) (Scm, error) {
+	path := dir
+	for i := 0; i < levels; i++ {
+		for key, scm := range scms {
+			if isDir(filepath.Join(path, key)) {
+				return scm, nil
+			}
+		}
+
+		path = filepath.Join(path, "..")
+	}
+
+	return nil, fmt.Errorf("unknown scm for %s", dir)
+}
+
+func isDir(path string) bool {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+
+	return stat.IsDir()
+}
+
+func (g Git) Checkout(dir, kind, spec string) error {
+	cmd := exec.Command("git", "checkout", spec)
+	cmd.Dir = dir
+	return cmd.Run()
+}
+
+func (g Git) Revision(dir string) (string, error) {
+	return output(dir, "git", "rev-parse", "HEAD")
+}
+
+func (g Git) Time(dir string) (time.Time, error) {
+	out, err := output(dir, "git", "log", "-1", "--format=%ct")
+	if err != nil {
+		return time.Time{}, err
+	}
+	return parseUnix(out)
+}
+
+func (h Hg) Checkout(dir, kind, spec string) error {
+	var cmd *exec.Cmd
+
+	if kind == Commit {
+		cmd = exec.Command("hg", "update", "-c", spec)
+	} else {
+		cmd = exec.Command("hg", "checkout", spec)
+	}
+
+	cmd.Dir = dir
+	return cmd.Run()
+}
+
+func (h Hg) Revision(dir string) (string, error) {
+	return output(dir, "hg", "log", "-r", ".", "--template", "{node}")
+}
+
+func (h Hg) Time(dir string) (time.Time, error) {
+	out, err := output(dir, "hg", "log", "-r", ".", "--template", "{date|hgdate}")
+	if err != nil {
+		return time.Time{}, err
+	}
+	// seconds and the timezone offset
+	return parseUnix(strings.Fields(out + " ")[0])
+}
+
+func (s Svn) Checkout(dir, kind, spec string) error {
+	var cmd *exec.Cmd
+
+	switch kind {
+	case Commit:
+		cmd = exec.Command("svn", "up", "-r", spec)
+	case Branch:
+		cmd = exec.Command("svn", "switch", "^/branches/"+spec)
+	case Tag:
+		cmd = exec.Command("svn", "switch", "^/tags/"+spec)
+	default:
+		return fmt.Errorf("unknown checkout %s %s", kind, spec)
+	}
+
+	cmd.Dir = dir
+	return cmd.Run()
+}
+
+func (s Svn) Revision(dir string) (string, error) {
+	return output(dir, "svn", "info", "--show-item", "revision")
+}
+
+func (s Svn) Time(dir string) (time.Time, error) {
+	out, err := output(dir, "svn", "info", "--show-item", "last-changed-date")
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Parse(time.RFC3339Nano, out)
+}
+
+func parseUnix(s string) (time.Time, error) {
+	seconds, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(seconds, 0).UTC(), nil
+}
+
+// Run the command in dir, returning its output without surrounding space.
+func output(dir, name string, args ...string) (string, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%s %s failed in %s: %s", name, strings.Join(args, " "), dir, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
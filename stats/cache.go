@@ -0,0 +1,107 @@
+package stats
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// What the analysis needs from a source file, cached on disk
+// so that files that didn't change are never parsed again.
+type fileEntry struct {
+	Size    int64
+	ModTime time.Time
+	Package string
+	Imports []importEntry
+	// Build tags the file is conditioned on.
+	Tags []string
+	// Exported identifiers referenced by import path,
+	// only when the file was parsed looking for them.
+	Symbols    map[string]map[string]int
+	HasSymbols bool
+}
+
+type importEntry struct {
+	Path   string
+	Offset int
+	Line   int
+	Column int
+}
+
+// Entries by file path, relative to the analyzed directory.
+type fileCache struct {
+	path    string
+	entries map[string]*fileEntry
+	// Entries of the files found during this analysis,
+	// the only ones written back so deleted files are dropped.
+	seen map[string]*fileEntry
+	mu   sync.Mutex
+}
+
+// Load the cache at path, an empty one if it doesn't exist or it's unreadable.
+func loadCache(path string) *fileCache {
+	c := &fileCache{
+		path:    path,
+		entries: make(map[string]*fileEntry),
+		seen:    make(map[string]*fileEntry),
+	}
+	if path == "" {
+		return c
+	}
+
+	dat, err := ioutil.ReadFile(path)
+	if err == nil {
+		if json.Unmarshal(dat, &c.entries) != nil {
+			c.entries = make(map[string]*fileEntry)
+		}
+	}
+	return c
+}
+
+// The entry of the file, if it didn't change since it was cached
+// and it has the symbols when they are needed.
+func (c *fileCache) lookup(rel string, info os.FileInfo, symbols bool) (*fileEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, found := c.entries[rel]
+	if !found || e.Size != info.Size() || !e.ModTime.Equal(info.ModTime()) || (symbols && !e.HasSymbols) {
+		return nil, false
+	}
+	c.seen[rel] = e
+	return e, true
+}
+
+func (c *fileCache) store(rel string, info os.FileInfo, e *fileEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e.Size = info.Size()
+	e.ModTime = info.ModTime()
+	c.seen[rel] = e
+}
+
+// Write the entries of the files found back to disk.
+func (c *fileCache) save() error {
+	if c.path == "" {
+		return nil
+	}
+
+	dat, err := json.Marshal(c.seen)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	// write it aside first so concurrent runs never read half a cache
+	tmp := c.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, dat, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
@@ -0,0 +1,133 @@
+package stats
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/build/constraint"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Options narrow the analysis down to the files built for a set of targets.
+type Options struct {
+	// GOOS/GOARCH pairs the project is built for.
+	// Every file is analyzed, whatever its constraints, when empty.
+	Platforms []string
+	// Build tags set in every build.
+	Tags []string
+	// Patterns of the files and directories left out of the analysis.
+	Ignore []string
+	// File caching what's found in every source file between
+	// analyses, nothing is cached when empty.
+	Cache string
+	// Files parsed at the same time, as many as CPUs when zero.
+	Workers int
+	// Import path of the project's repository.
+	Repository string
+	// Import paths of the dependencies managed in gopack.config.
+	Managed []string
+	// Parse the whole files to find the symbols used of every import.
+	Symbols bool
+	// Environment of the go command whose standard library the imports
+	// are classified with, gp's own when nil.
+	Env []string
+}
+
+// Split a GOOS/GOARCH pair.
+func ParsePlatform(platform string) (goos, goarch string, err error) {
+	parts := strings.Split(platform, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("%s is not a GOOS/GOARCH pair", platform)
+	}
+	return parts[0], parts[1], nil
+}
+
+// Platforms of the target set the file is built for, the same way
+// the go tool decides it: looking at its name and build constraints.
+func (o *Options) matchPlatforms(path string) ([]string, error) {
+	platforms := []string{}
+	for _, platform := range o.Platforms {
+		goos, goarch, err := ParsePlatform(platform)
+		if err != nil {
+			return nil, err
+		}
+
+		ctxt := build.Default
+		ctxt.GOOS = goos
+		ctxt.GOARCH = goarch
+		ctxt.BuildTags = o.Tags
+
+		match, err := ctxt.MatchFile(filepath.Dir(path), filepath.Base(path))
+		if err != nil {
+			return nil, err
+		}
+		if match {
+			platforms = append(platforms, platform)
+		}
+	}
+	return platforms, nil
+}
+
+// Build tags, other than operating systems, architectures and
+// releases, the file's build constraints are conditioned on.
+func constraintTags(f *ast.File) []string {
+	tags := []string{}
+	for _, group := range f.Comments {
+		if group.Pos() > f.Package {
+			break
+		}
+
+		for _, c := range group.List {
+			if !constraint.IsGoBuild(c.Text) && !constraint.IsPlusBuild(c.Text) {
+				continue
+			}
+
+			expr, err := constraint.Parse(c.Text)
+			if err != nil {
+				continue
+			}
+			expr.Eval(func(tag string) bool {
+				if !knownTags[tag] && !strings.HasPrefix(tag, "go1.") {
+					tags = appendUnique(tags, tag)
+				}
+				return true
+			})
+		}
+	}
+	return tags
+}
+
+func appendUnique(list []string, values ...string) []string {
+	for _, v := range values {
+		found := false
+		for _, l := range list {
+			if l == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, v)
+		}
+	}
+	sort.Strings(list)
+	return list
+}
+
+// Tags set by the go tool itself.
+var knownTags = map[string]bool{
+	"aix": true, "android": true, "darwin": true, "dragonfly": true, "freebsd": true,
+	"hurd": true, "illumos": true, "ios": true, "js": true, "linux": true, "nacl": true,
+	"netbsd": true, "openbsd": true, "plan9": true, "solaris": true, "wasip1": true,
+	"windows": true, "zos": true,
+
+	"386": true, "amd64": true, "amd64p32": true, "arm": true, "armbe": true, "arm64": true,
+	"arm64be": true, "loong64": true, "mips": true, "mipsle": true, "mips64": true,
+	"mips64le": true, "mips64p32": true, "mips64p32le": true, "ppc": true, "ppc64": true,
+	"ppc64le": true, "riscv": true, "riscv64": true, "s390": true, "s390x": true,
+	"sparc": true, "sparc64": true, "wasm": true,
+
+	"unix": true, "cgo": true, "gc": true, "gccgo": true,
+}
@@ -0,0 +1,31 @@
+package stats
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// The go tool ignores testdata directories and
+// files and directories starting with _ or a dot.
+func skippedName(name string) bool {
+	return name == "testdata" || strings.HasPrefix(name, "_") || strings.HasPrefix(name, ".")
+}
+
+// Whether the path, relative to the analyzed directory, matches one of
+// the ignore patterns. Patterns without a slash match any file or
+// directory by name, the others match the whole relative path.
+func (o *Options) ignored(rel string) bool {
+	rel = filepath.ToSlash(rel)
+	for _, pattern := range o.Ignore {
+		pattern = strings.TrimSuffix(filepath.ToSlash(pattern), "/")
+		name := rel
+		if !strings.Contains(pattern, "/") {
+			name = path.Base(rel)
+		}
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package stats
+
+import (
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// Where an imported package comes from.
+type Origin int
+
+const (
+	Stdlib Origin = iota - 1
+	// Relative imports, like "./foo".
+	Local
+	Remote
+	// Packages inside the project's own repository.
+	Project
+)
+
+func (o Origin) String() string {
+	switch o {
+	case Remote:
+		return "R"
+	case Project:
+		return "P"
+	case Local:
+		return "L"
+	}
+	return "S"
+}
+
+// Tell where an import comes from: relative paths are local, packages
+// under the repository and the managed dependencies belong to them, the
+// standard library is the one of the active toolchain and anything else
+// has to be fetched from somewhere.
+func Classify(importPath string, opts Options) Origin {
+	if importPath == "." || strings.HasPrefix(importPath, "./") || strings.HasPrefix(importPath, "../") {
+		return Local
+	}
+	if opts.Repository != "" && under(importPath, opts.Repository) {
+		return Project
+	}
+	for _, managed := range opts.Managed {
+		if under(importPath, managed) {
+			return Remote
+		}
+	}
+
+	std := stdlibPackages(opts.Env)
+	if len(std) == 0 {
+		// without a toolchain around, guess it from the host name
+		if strings.Contains(strings.Split(importPath, "/")[0], ".") {
+			return Remote
+		}
+		return Stdlib
+	}
+	if std[importPath] {
+		return Stdlib
+	}
+	return Remote
+}
+
+// Whether the import is the package at root or one of its subpackages.
+func under(importPath, root string) bool {
+	return importPath == root || strings.HasPrefix(importPath, root+"/")
+}
+
+var (
+	stdlib     map[string]bool
+	stdlibOnce sync.Once
+)
+
+// Packages of the standard library, as listed by the go command on the
+// PATH run with env, rather than the one gp was built with.
+func stdlibPackages(env []string) map[string]bool {
+	stdlibOnce.Do(func() {
+		stdlib = make(map[string]bool)
+		cmd := exec.Command("go", "list", "std")
+		cmd.Env = env
+		out, err := cmd.Output()
+		if err != nil {
+			return
+		}
+		for _, pkg := range strings.Fields(string(out)) {
+			// the vendored packages aren't importable
+			if !strings.HasPrefix(pkg, "vendor/") {
+				stdlib[pkg] = true
+			}
+		}
+	})
+	return stdlib
+}
@@ -0,0 +1,163 @@
+package stats
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// Imports of one of the project's packages, a directory of the analyzed tree.
+type PackageStats struct {
+	// Directory relative to the analyzed one, "." for the root.
+	Dir string
+	// Files referencing every import of the package.
+	Files map[string][]string
+	// Remote imports of the package, sorted.
+	Deps []string
+	// Packages of the project importing this one.
+	FanIn int
+}
+
+// Distinct imports of the package.
+func (p *PackageStats) FanOut() int {
+	return len(p.Files)
+}
+
+// Remote imports referenced from a single file of the package,
+// which are cheap to get rid of.
+func (p *PackageStats) SingleFileDeps() []string {
+	deps := []string{}
+	for _, dep := range p.Deps {
+		if len(p.Files[dep]) == 1 {
+			deps = append(deps, dep)
+		}
+	}
+	return deps
+}
+
+// How much the project depends on a remote import.
+type Coupling struct {
+	Path string
+	// Packages of the project importing it.
+	Packages int
+	// References to it in the source.
+	References int
+}
+
+// Statistics of every package of the project, sorted by directory.
+func (ps *ProjectStats) Packages() []*PackageStats {
+	byDir := make(map[string]*PackageStats)
+	for importPath, s := range ps.ImportStatsByPath {
+		for _, ref := range s.ReferencePositions {
+			dir := ps.packageDir(ref.Filename)
+			p, found := byDir[dir]
+			if !found {
+				p = &PackageStats{Dir: dir, Files: make(map[string][]string)}
+				byDir[dir] = p
+			}
+			p.Files[importPath] = appendUnique(p.Files[importPath], ref.Filename)
+		}
+	}
+
+	for _, p := range byDir {
+		for importPath := range p.Files {
+			s := ps.ImportStatsByPath[importPath]
+			if s.Remote {
+				p.Deps = append(p.Deps, importPath)
+			}
+			if target, found := byDir[ps.importedDir(p.Dir, s)]; found && target != p {
+				target.FanIn++
+			}
+		}
+		sort.Strings(p.Deps)
+	}
+
+	packages := make([]*PackageStats, 0, len(byDir))
+	for _, p := range byDir {
+		packages = append(packages, p)
+	}
+	sort.Slice(packages, func(i, j int) bool { return packages[i].Dir < packages[j].Dir })
+	return packages
+}
+
+// Remote imports sorted by the number of packages using them, then by references.
+func (ps *ProjectStats) Coupling() []Coupling {
+	packages := make(map[string]int)
+	for _, p := range ps.Packages() {
+		for _, dep := range p.Deps {
+			packages[dep]++
+		}
+	}
+
+	couplings := []Coupling{}
+	for dep, n := range packages {
+		couplings = append(couplings, Coupling{
+			Path:       dep,
+			Packages:   n,
+			References: len(ps.ImportStatsByPath[dep].ReferencePositions),
+		})
+	}
+	sort.Slice(couplings, func(i, j int) bool {
+		ci, cj := couplings[i], couplings[j]
+		if ci.Packages != cj.Packages {
+			return ci.Packages > cj.Packages
+		}
+		if ci.References != cj.References {
+			return ci.References > cj.References
+		}
+		return ci.Path < cj.Path
+	})
+	return couplings
+}
+
+func (ps *ProjectStats) PrintPackageSummary(w io.Writer) {
+	writer := tabwriter.NewWriter(w, 0, 8, 1, '\t', 0)
+
+	fmt.Fprint(writer, "Package stats summary:\n\n")
+	fmt.Fprintln(writer, "Package\tFan-in\tFan-out\tRemote imports")
+	for _, p := range ps.Packages() {
+		fmt.Fprintf(writer, "%s\t%d\t%d\t%d\n", p.Dir, p.FanIn, p.FanOut(), len(p.Deps))
+		for _, dep := range p.Deps {
+			if files := len(p.Files[dep]); files == 1 {
+				fmt.Fprintf(writer, "  %s\t\t\t1 file, cheap to remove\n", dep)
+			} else {
+				fmt.Fprintf(writer, "  %s\t\t\t%d files\n", dep, files)
+			}
+		}
+	}
+
+	fmt.Fprint(writer, "\nMost coupled dependencies:\n\n")
+	fmt.Fprintln(writer, "Import\tPackages\tReferences")
+	for _, c := range ps.Coupling() {
+		fmt.Fprintf(writer, "%s\t%d\t%d\n", c.Path, c.Packages, c.References)
+	}
+	writer.Flush()
+}
+
+// Directory of the package holding the file, relative to the analyzed one.
+func (ps *ProjectStats) packageDir(filename string) string {
+	dir := filepath.Dir(filename)
+	if rel, err := filepath.Rel(ps.dir, dir); err == nil {
+		dir = rel
+	}
+	return filepath.ToSlash(dir)
+}
+
+// Directory of the project the import points at, if it's one of its packages.
+func (ps *ProjectStats) importedDir(from string, s *ImportStats) string {
+	switch s.Origin {
+	case Local:
+		return path.Join(from, s.Path)
+	case Project:
+		rel := strings.TrimPrefix(strings.TrimPrefix(s.Path, ps.options.Repository), "/")
+		if rel == "" {
+			return "."
+		}
+		return rel
+	}
+	return ""
+}
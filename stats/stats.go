@@ -0,0 +1,408 @@
+// Package stats analyzes the imports of a source tree.
+package stats
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+)
+
+type ProjectStats struct {
+	ImportStatsByPath map[string]*ImportStats
+
+	// the analyzed directory
+	dir     string
+	options Options
+}
+
+// Kind of code a reference to an import comes from.
+type Scope int
+
+const (
+	Production Scope = iota
+	// _test.go files of the package itself
+	InternalTest
+	// _test.go files of the package's _test package
+	ExternalTest
+)
+
+type ImportStats struct {
+	Path   string
+	Origin Origin
+	// Whether the import has to be fetched, see Origin.
+	Remote             bool
+	ReferencePositions []token.Position
+	// Scope of every reference, in the same order as ReferencePositions.
+	ReferenceScopes []Scope
+	// Platforms of the target set that build the import,
+	// empty when the analysis doesn't have a target set.
+	Platforms []string
+	// Build tags the files importing it are conditioned on.
+	Tags []string
+	// References to each exported identifier of the import.
+	Symbols map[string]int
+}
+
+type SummaryItem struct {
+	Origin Origin
+	Sum    int
+	Path   string
+	// References from production code, internal and external tests.
+	Production   int
+	InternalTest int
+	ExternalTest int
+	// Platforms and tags the import is restricted to, if any.
+	Constraints string
+}
+
+func (i SummaryItem) Legend() string {
+	legend := fmt.Sprintf("%s\t%s\t%d\t%d\t%d\t%d", i.Origin, i.Path, i.Sum, i.Production, i.InternalTest, i.ExternalTest)
+	if i.Constraints != "" {
+		legend = fmt.Sprintf("%s\t%s", legend, i.Constraints)
+	}
+	return legend
+}
+
+type Summary struct {
+	Items []SummaryItem
+}
+
+func (s *Summary) Append(i SummaryItem)  { s.Items = append(s.Items, i) }
+func (s *Summary) Get(i int) SummaryItem { return s.Items[i] }
+
+func (s *Summary) Len() int      { return len(s.Items) }
+func (s *Summary) Swap(i, j int) { s.Items[i], s.Items[j] = s.Items[j], s.Items[i] }
+func (s *Summary) Less(i, j int) bool {
+	i1 := s.Items[i]
+	i2 := s.Items[j]
+
+	if i1.Origin != i2.Origin {
+		return i1.Origin > i2.Origin
+	}
+	if i1.Sum != i2.Sum {
+		return i1.Sum > i2.Sum
+	}
+	return i1.Path < i2.Path
+}
+
+func NewProjectStats() *ProjectStats {
+	return &ProjectStats{
+		ImportStatsByPath: make(map[string]*ImportStats),
+	}
+}
+
+// Analyze every Go file in the tree, whatever its build constraints.
+func AnalyzeSourceTree(dir string) (*ProjectStats, error) {
+	return Analyze(dir, Options{})
+}
+
+// A Go file found walking the analyzed directory.
+type sourceFile struct {
+	path string
+	// path relative to the analyzed directory
+	rel  string
+	info os.FileInfo
+
+	platforms []string
+	entry     *fileEntry
+	err       error
+}
+
+// Analyze the Go files in the tree built for the targets in opts.
+func Analyze(dir string, opts Options) (*ProjectStats, error) {
+	ps := NewProjectStats()
+	ps.dir = dir
+	ps.options = opts
+	files := []*sourceFile{}
+	err := filepath.Walk(
+		dir,
+		func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			// the analyzed directory is never skipped, even if it's
+			// inside the gopack directory, like the test projects
+			if path == dir {
+				return nil
+			}
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+
+			baseName := info.Name()
+			if info.IsDir() {
+				// skips the gopack directory and the vendored code too,
+				// like the vendor directories the go tool leaves out of ./...
+				if skippedName(baseName) || baseName == "vendor" || opts.ignored(rel) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if strings.HasSuffix(baseName, ".go") && !skippedName(baseName) && !opts.ignored(rel) {
+				files = append(files, &sourceFile{path: path, rel: rel, info: info})
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	cache := loadCache(opts.Cache)
+	ps.parseSourceFiles(files, cache)
+
+	// merge the results in walk order to keep references sorted
+	for _, f := range files {
+		if f.err != nil {
+			return nil, f.err
+		}
+		if f.entry != nil {
+			ps.foundImports(f)
+		}
+	}
+
+	if err := cache.save(); err != nil {
+		return nil, err
+	}
+	return ps, nil
+}
+
+// Parse the files spread across the workers.
+func (ps *ProjectStats) parseSourceFiles(files []*sourceFile, cache *fileCache) {
+	workers := ps.options.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	jobs := make(chan *sourceFile)
+	var wg sync.WaitGroup
+	for n := 0; n < workers; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for f := range jobs {
+				ps.analyzeSourceFile(f, cache)
+			}
+		}()
+	}
+
+	for _, f := range files {
+		jobs <- f
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// Find the platforms the file is built for and its imports,
+// leaving the entry empty when no target builds it.
+func (ps *ProjectStats) analyzeSourceFile(f *sourceFile, cache *fileCache) {
+	if len(ps.options.Platforms) > 0 {
+		matches, err := ps.options.matchPlatforms(f.path)
+		if err != nil {
+			f.err = err
+			return
+		}
+		// not built for any of the targets
+		if len(matches) == 0 {
+			return
+		}
+		f.platforms = matches
+	}
+
+	if entry, found := cache.lookup(f.rel, f.info, ps.options.Symbols); found {
+		f.entry = entry
+		return
+	}
+
+	entry, err := parseSourceFile(f.path, ps.options.Symbols)
+	if err != nil {
+		f.err = err
+		return
+	}
+	cache.store(f.rel, f.info, entry)
+	f.entry = entry
+}
+
+// Parse the file up to its imports, which is all the analysis
+// needs unless it looks for the symbols used too.
+func parseSourceFile(path string, symbols bool) (*fileEntry, error) {
+	mode := parser.ParseComments
+	if !symbols {
+		mode |= parser.ImportsOnly
+	}
+
+	fs := token.NewFileSet()
+	f, err := parser.ParseFile(fs, path, nil, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &fileEntry{Package: f.Name.Name, Tags: constraintTags(f), HasSymbols: symbols}
+	if symbols {
+		if entry.Symbols, err = fileSymbols(f); err != nil {
+			return nil, err
+		}
+	}
+	for _, i := range f.Imports {
+		importPath, err := strconv.Unquote(i.Path.Value)
+		if err != nil {
+			return nil, err
+		}
+		pos := fs.Position(i.Pos())
+		entry.Imports = append(entry.Imports, importEntry{
+			Path:   importPath,
+			Offset: pos.Offset,
+			Line:   pos.Line,
+			Column: pos.Column,
+		})
+	}
+	return entry, nil
+}
+
+// Tell production code from internal and external tests.
+func fileScope(path, pkg string) Scope {
+	if !strings.HasSuffix(path, "_test.go") {
+		return Production
+	}
+	if strings.HasSuffix(pkg, "_test") {
+		return ExternalTest
+	}
+	return InternalTest
+}
+
+func (ps *ProjectStats) foundImports(f *sourceFile) {
+	scope := fileScope(f.path, f.entry.Package)
+	for _, i := range f.entry.Imports {
+		ref := token.Position{Filename: f.path, Offset: i.Offset, Line: i.Line, Column: i.Column}
+		s, found := ps.ImportStatsByPath[i.Path]
+		if found {
+			s.ReferencePositions = append(s.ReferencePositions, ref)
+		} else {
+			s = NewImportStats(i.Path, ref)
+			s.setOrigin(Classify(i.Path, ps.options))
+			ps.ImportStatsByPath[i.Path] = s
+		}
+		s.ReferenceScopes = append(s.ReferenceScopes, scope)
+		s.Platforms = appendUnique(s.Platforms, f.platforms...)
+		s.Tags = appendUnique(s.Tags, f.entry.Tags...)
+	}
+
+	for importPath, symbols := range f.entry.Symbols {
+		s := ps.ImportStatsByPath[importPath]
+		if s.Symbols == nil {
+			s.Symbols = make(map[string]int)
+		}
+		for name, n := range symbols {
+			s.Symbols[name] += n
+		}
+	}
+}
+
+// Platforms the project was analyzed for, empty when
+// every file was analyzed whatever its build constraints.
+func (ps *ProjectStats) Platforms() []string {
+	return ps.options.Platforms
+}
+
+func (ps *ProjectStats) IsImportUsed(importPath string) bool {
+	_, used := ps.ImportStatsByPath[importPath]
+	return used
+}
+
+func (ps *ProjectStats) PrintSummary(w io.Writer) {
+	writer := tabwriter.NewWriter(w, 0, 8, 0, '\t', 0)
+	summary := ps.GetSummary()
+
+	fmt.Fprint(writer, "Import stats summary:\n\n")
+	fmt.Fprintln(writer, "\tImport\tAll\tProd\tTest\tXTest")
+	for _, item := range summary.Items {
+		fmt.Fprintln(writer, item.Legend())
+	}
+	fmt.Fprintln(writer, "\nR Remote, P Project, L Local, S Stdlib")
+	fmt.Fprintln(writer, "Prod production code, Test internal tests, XTest external _test packages")
+	writer.Flush()
+}
+
+func (ps *ProjectStats) GetSummary() *Summary {
+	summary := &Summary{Items: []SummaryItem{}}
+
+	for k, v := range ps.ImportStatsByPath {
+		item := SummaryItem{
+			Path:         k,
+			Sum:          len(v.ReferencePositions),
+			Production:   len(v.References(Production)),
+			InternalTest: len(v.References(InternalTest)),
+			ExternalTest: len(v.References(ExternalTest)),
+			Origin:       v.Origin,
+			Constraints:  ps.constraints(v),
+		}
+		summary.Append(item)
+	}
+	sort.Sort(summary)
+
+	return summary
+}
+
+func NewImportStats(importPath string, pos token.Position) *ImportStats {
+	s := &ImportStats{
+		Path:               importPath,
+		ReferencePositions: []token.Position{pos},
+	}
+	s.setOrigin(Classify(importPath, Options{}))
+	return s
+}
+
+func (i *ImportStats) setOrigin(o Origin) {
+	i.Origin = o
+	i.Remote = o == Remote
+}
+
+// Describe the platforms and tags an import is restricted to,
+// nothing when every target builds it without extra tags.
+func (ps *ProjectStats) constraints(s *ImportStats) string {
+	parts := []string{}
+	if len(s.Platforms) < len(ps.options.Platforms) {
+		parts = append(parts, s.Platforms...)
+	}
+	for _, tag := range s.Tags {
+		parts = append(parts, "+"+tag)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Positions of the references coming from the scope.
+func (i *ImportStats) References(scope Scope) []token.Position {
+	refs := []token.Position{}
+	for n, ref := range i.ReferencePositions {
+		if n < len(i.ReferenceScopes) && i.ReferenceScopes[n] == scope {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+// Whether only tests reference the import.
+func (i *ImportStats) TestOnly() bool {
+	return len(i.References(Production)) == 0
+}
+
+func (i *ImportStats) ReferenceList() string {
+	return FormatReferences(i.ReferencePositions)
+}
+
+func FormatReferences(positions []token.Position) string {
+	lines := []string{}
+	for _, ref := range positions {
+		lines = append(lines, fmt.Sprintf("%s:%d", ref.Filename, ref.Line))
+	}
+	return fmt.Sprintf("* %s", strings.Join(lines, "\n* "))
+}
@@ -0,0 +1,640 @@
+package stats
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/d2fn/gopack"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"testing"
+)
+
+func check(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
+
+func setupTestDir() string {
+	dir, err := ioutil.TempDir("", "gopack-stats-")
+	check(err)
+	return dir
+}
+
+func createSourceFixture(dir, name, fixture string) {
+	os.MkdirAll(dir, 0755)
+	err := ioutil.WriteFile(path.Join(dir, name), []byte(fixture), 0644)
+	check(err)
+}
+
+func TestAnalyzeSourceTree(t *testing.T) {
+	pwd := setupTestDir()
+	createSourceFixture(pwd, "foo.go", `package main
+import "github.com/pelletier/go-toml"
+`)
+
+	stats, err := AnalyzeSourceTree(pwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.ImportStatsByPath["github.com/pelletier/go-toml"] == nil {
+		t.Error("Expected to have go-toml in the dependencies tree")
+	}
+}
+
+func TestAnalyzeSourceTreeIgnoresGopack(t *testing.T) {
+	pwd := setupTestDir()
+
+	createSourceFixture(pwd, "foo.go", `package main
+import "github.com/pelletier/go-toml"
+`)
+
+	createSourceFixture(path.Join(pwd, gopack.GopackDir, "src"), "foo.go", `package main
+import "github.com/pelletier/go-toml"
+`)
+
+	stats, err := AnalyzeSourceTree(pwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	i := stats.ImportStatsByPath["github.com/pelletier/go-toml"]
+	if len(i.ReferencePositions) == 2 {
+		t.Error("Expected to ignore the gopack directory")
+	}
+}
+
+func TestReferenceDifferentDependencies(t *testing.T) {
+	pwd := setupTestDir()
+
+	createSourceFixture(pwd, "foo.go", `package main
+import "github.com/pelletier/go-toml"
+`)
+
+	createSourceFixture(pwd, "bar.go", `package main
+import "github.com/gorilla/mux"
+`)
+
+	stats, err := AnalyzeSourceTree(pwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	istats := stats.ImportStatsByPath["github.com/pelletier/go-toml"]
+	if !istats.Remote {
+		t.Errorf("Expected reference to be remote\n")
+	}
+
+	if len(istats.ReferencePositions) != 1 {
+		t.Errorf("Expected to have 1 reference to github.com/pelletier/go-toml\n")
+	}
+
+	istats = stats.ImportStatsByPath["github.com/gorilla/mux"]
+	if !istats.Remote {
+		t.Errorf("Expected reference to be remote\n")
+	}
+
+	if len(istats.ReferencePositions) != 1 {
+		t.Errorf("Expected to have 1 reference to github.com/gorilla/mux\n")
+	}
+}
+
+func TestReferenceSameDependencies(t *testing.T) {
+	pwd := setupTestDir()
+
+	createSourceFixture(pwd, "foo.go", `package main
+import "github.com/pelletier/go-toml"
+`)
+
+	createSourceFixture(pwd, "bar.go", `package main
+import "fmt"
+import "github.com/pelletier/go-toml"
+`)
+
+	stats, err := AnalyzeSourceTree(pwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	istats := stats.ImportStatsByPath["github.com/pelletier/go-toml"]
+	if !istats.Remote {
+		t.Errorf("Expected reference to be remote\n")
+	}
+
+	if len(istats.ReferencePositions) != 2 {
+		t.Errorf("Expected to have 2 references to github.com/pelletier/go-toml\n")
+	}
+
+	expected := fmt.Sprintf("* %s/bar.go:3\n* %s/foo.go:2", pwd, pwd)
+
+	list := istats.ReferenceList()
+	if list != expected {
+		t.Errorf("Expected reference list to be %s but it was %s\n", expected, list)
+	}
+}
+
+func TestReferenceLocalDependencies(t *testing.T) {
+	pwd := setupTestDir()
+
+	createSourceFixture(pwd, "bar.go", `package main
+import "fmt"
+`)
+
+	stats, err := AnalyzeSourceTree(pwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	istats := stats.ImportStatsByPath["fmt"]
+	if istats.Remote {
+		t.Errorf("Expected reference to not be remote\n")
+	}
+}
+
+func TestUsedDependencies(t *testing.T) {
+	pwd := setupTestDir()
+
+	createSourceFixture(pwd, "bar.go", `package main
+import "fmt"
+`)
+
+	stats, err := AnalyzeSourceTree(pwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !stats.IsImportUsed("fmt") {
+		t.Errorf("Expected fmt to be used\n")
+	}
+
+	if stats.IsImportUsed("os") {
+		t.Errorf("Expected os to not be used\n")
+	}
+}
+
+func TestGetStatsSummary(t *testing.T) {
+	pwd := setupTestDir()
+
+	createSourceFixture(pwd, "foo.go", `package main
+import "github.com/pelletier/go-toml"
+`)
+
+	createSourceFixture(pwd, "bar.go", `package main
+import "fmt"
+import "./foo"
+import "github.com/pelletier/go-foo"
+import "github.com/pelletier/go-toml"
+`)
+
+	stats, err := AnalyzeSourceTree(pwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := stats.GetSummary()
+
+	checkSumaryItem(t, s.Get(0), "github.com/pelletier/go-toml", "R	github.com/pelletier/go-toml	2	2	0	0")
+	checkSumaryItem(t, s.Get(1), "github.com/pelletier/go-foo", "R	github.com/pelletier/go-foo	1	1	0	0")
+	checkSumaryItem(t, s.Get(2), "./foo", "L	./foo	1	1	0	0")
+	checkSumaryItem(t, s.Get(3), "fmt", "S	fmt	1	1	0	0")
+}
+
+func checkSumaryItem(t *testing.T, item SummaryItem, path, legend string) {
+	if item.Path != path {
+		t.Errorf("Expected item to be %s\n", path)
+	}
+
+	actual := item.Legend()
+	if actual != legend {
+		t.Errorf("Expected legend to be %s, but was %s\n", legend, actual)
+	}
+}
+
+func TestAnalyzeForPlatforms(t *testing.T) {
+	pwd := setupTestDir()
+
+	createSourceFixture(pwd, "foo.go", `package main
+import "github.com/pelletier/go-toml"
+`)
+
+	createSourceFixture(pwd, "foo_windows.go", `package main
+import "github.com/golang/winapi"
+`)
+
+	createSourceFixture(pwd, "bar.go", `//go:build linux && integration
+
+package main
+import "github.com/gorilla/mux"
+`)
+
+	createSourceFixture(pwd, "baz.go", `// +build darwin
+
+package main
+import "github.com/pelletier/go-toml"
+`)
+
+	stats, err := Analyze(pwd, Options{Platforms: []string{"linux/amd64", "darwin/amd64"}, Tags: []string{"integration"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.IsImportUsed("github.com/golang/winapi") {
+		t.Error("Expected to ignore imports from files for other platforms")
+	}
+
+	istats := stats.ImportStatsByPath["github.com/gorilla/mux"]
+	if len(istats.Platforms) != 1 || istats.Platforms[0] != "linux/amd64" {
+		t.Errorf("Expected github.com/gorilla/mux to be needed for linux/amd64 only, but it was %v\n", istats.Platforms)
+	}
+
+	if len(istats.Tags) != 1 || istats.Tags[0] != "integration" {
+		t.Errorf("Expected github.com/gorilla/mux to need the integration tag, but it was %v\n", istats.Tags)
+	}
+
+	istats = stats.ImportStatsByPath["github.com/pelletier/go-toml"]
+	if len(istats.Platforms) != 2 || len(istats.Tags) != 0 {
+		t.Errorf("Expected github.com/pelletier/go-toml to be needed for every platform without tags, but it was %v %v\n", istats.Platforms, istats.Tags)
+	}
+	if len(istats.ReferencePositions) != 2 {
+		t.Errorf("Expected to have 2 references to github.com/pelletier/go-toml\n")
+	}
+
+	s := stats.GetSummary()
+	checkSumaryItem(t, s.Get(1), "github.com/gorilla/mux", "R	github.com/gorilla/mux	1	1	0	0	linux/amd64 +integration")
+}
+
+func TestAnalyzeWithoutPlatforms(t *testing.T) {
+	pwd := setupTestDir()
+
+	createSourceFixture(pwd, "foo_windows.go", `package main
+import "github.com/golang/winapi"
+`)
+
+	stats, err := AnalyzeSourceTree(pwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	istats := stats.ImportStatsByPath["github.com/golang/winapi"]
+	if istats == nil || len(istats.Platforms) != 0 {
+		t.Error("Expected to analyze every file when there are no target platforms")
+	}
+}
+
+func TestReferenceScopes(t *testing.T) {
+	pwd := setupTestDir()
+
+	createSourceFixture(pwd, "foo.go", `package foo
+import "github.com/pelletier/go-toml"
+`)
+
+	createSourceFixture(pwd, "foo_test.go", `package foo
+import "github.com/pelletier/go-toml"
+import "github.com/stretchr/testify/assert"
+`)
+
+	createSourceFixture(pwd, "bar_test.go", `package foo_test
+import "github.com/stretchr/testify/assert"
+`)
+
+	stats, err := AnalyzeSourceTree(pwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	istats := stats.ImportStatsByPath["github.com/pelletier/go-toml"]
+	if istats.TestOnly() {
+		t.Error("Expected github.com/pelletier/go-toml to be used by production code")
+	}
+
+	istats = stats.ImportStatsByPath["github.com/stretchr/testify/assert"]
+	if !istats.TestOnly() {
+		t.Error("Expected github.com/stretchr/testify/assert to be used only by tests")
+	}
+
+	if len(istats.References(InternalTest)) != 1 || len(istats.References(ExternalTest)) != 1 {
+		t.Errorf("Expected 1 internal and 1 external test reference, but it was %v\n", istats.ReferenceScopes)
+	}
+
+	s := stats.GetSummary()
+	checkSumaryItem(t, s.Get(0), "github.com/pelletier/go-toml", "R	github.com/pelletier/go-toml	2	1	1	0")
+	checkSumaryItem(t, s.Get(1), "github.com/stretchr/testify/assert", "R	github.com/stretchr/testify/assert	2	0	1	1")
+}
+
+func TestAnalyzeSkipsLikeTheGoTool(t *testing.T) {
+	pwd := setupTestDir()
+
+	createSourceFixture(pwd, "foo.go", `package main
+import "github.com/pelletier/go-toml"
+`)
+
+	for _, dir := range []string{"testdata", "_examples", ".git", "vendor"} {
+		createSourceFixture(path.Join(pwd, dir), "foo.go", `package main
+import "github.com/gorilla/mux"
+`)
+	}
+
+	createSourceFixture(pwd, "_bar.go", `package main
+import "github.com/gorilla/mux"
+`)
+
+	stats, err := AnalyzeSourceTree(pwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.IsImportUsed("github.com/gorilla/mux") {
+		t.Error("Expected to skip testdata, vendor, underscore and dot files and directories")
+	}
+
+	if !stats.IsImportUsed("github.com/pelletier/go-toml") {
+		t.Error("Expected to analyze the rest of the files")
+	}
+}
+
+func TestAnalyzeIgnoring(t *testing.T) {
+	pwd := setupTestDir()
+
+	createSourceFixture(pwd, "foo.go", `package main
+import "github.com/pelletier/go-toml"
+`)
+
+	createSourceFixture(path.Join(pwd, "fixtures", "app"), "main.go", `package main
+import "github.com/gorilla/mux"
+`)
+
+	createSourceFixture(path.Join(pwd, "api"), "api_gen.go", `package api
+import "github.com/golang/protobuf/proto"
+`)
+
+	stats, err := Analyze(pwd, Options{Ignore: []string{"fixtures/", "*_gen.go"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.IsImportUsed("github.com/gorilla/mux") {
+		t.Error("Expected to ignore the fixtures directory")
+	}
+
+	if stats.IsImportUsed("github.com/golang/protobuf/proto") {
+		t.Error("Expected to ignore generated files in any directory")
+	}
+
+	if !stats.IsImportUsed("github.com/pelletier/go-toml") {
+		t.Error("Expected to analyze the rest of the files")
+	}
+}
+
+func TestAnalyzeWithCache(t *testing.T) {
+	pwd := setupTestDir()
+	cache := path.Join(pwd, gopack.GopackStatsCache)
+
+	createSourceFixture(pwd, "foo.go", `package main
+import "github.com/pelletier/go-toml"
+`)
+	foo := path.Join(pwd, "foo.go")
+	info, err := os.Stat(foo)
+	check(err)
+
+	if _, err := Analyze(pwd, Options{Cache: cache}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(cache); err != nil {
+		t.Fatalf("Expected the cache to be written: %s\n", err)
+	}
+
+	// same size and modification time, it must not be parsed again
+	createSourceFixture(pwd, "foo.go", `package main
+import "github.com/pelletier/go-yaml"
+`)
+	check(os.Chtimes(foo, info.ModTime(), info.ModTime()))
+
+	stats, err := Analyze(pwd, Options{Cache: cache})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stats.IsImportUsed("github.com/pelletier/go-toml") {
+		t.Error("Expected to use the cached imports of unchanged files")
+	}
+
+	createSourceFixture(pwd, "foo.go", `package main
+import "github.com/gorilla/mux"
+`)
+
+	stats, err = Analyze(pwd, Options{Cache: cache})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stats.IsImportUsed("github.com/gorilla/mux") {
+		t.Error("Expected to parse the files that changed again")
+	}
+}
+
+func TestClassify(t *testing.T) {
+	opts := Options{Repository: "github.com/d2fn/gopack", Managed: []string{"gopkg.in/yaml.v2", "corp/tools"}}
+
+	for importPath, expected := range map[string]Origin{
+		"fmt":                                 Stdlib,
+		"net/http/httptest":                   Stdlib,
+		"./foo":                               Local,
+		"../foo":                              Local,
+		"github.com/d2fn/gopack":              Project,
+		"github.com/d2fn/gopack/internal/foo": Project,
+		"github.com/d2fn/gopackage":           Remote,
+		"gopkg.in/yaml.v2":                    Remote,
+		"corp/tools/lint":                     Remote,
+		"corp/other":                          Remote,
+	} {
+		if origin := Classify(importPath, opts); origin != expected {
+			t.Errorf("Expected %s to be classified as %s but it was %s\n", importPath, expected, origin)
+		}
+	}
+}
+
+func TestSummaryOfProjectImports(t *testing.T) {
+	pwd := setupTestDir()
+
+	createSourceFixture(pwd, "foo.go", `package main
+import "fmt"
+import "github.com/d2fn/gopack/stats"
+import "github.com/pelletier/go-toml"
+`)
+
+	stats, err := Analyze(pwd, Options{Repository: "github.com/d2fn/gopack"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.ImportStatsByPath["github.com/d2fn/gopack/stats"].Remote {
+		t.Error("Expected packages of the project to not be remote")
+	}
+
+	s := stats.GetSummary()
+	checkSumaryItem(t, s.Get(0), "github.com/d2fn/gopack/stats", "P	github.com/d2fn/gopack/stats	1	1	0	0")
+	checkSumaryItem(t, s.Get(1), "github.com/pelletier/go-toml", "R	github.com/pelletier/go-toml	1	1	0	0")
+	checkSumaryItem(t, s.Get(2), "fmt", "S	fmt	1	1	0	0")
+}
+
+func TestPackageStats(t *testing.T) {
+	pwd := setupTestDir()
+
+	createSourceFixture(pwd, "main.go", `package main
+import "github.com/d2fn/gopack/config"
+import "github.com/d2fn/gopack/deps"
+import "github.com/gorilla/mux"
+`)
+
+	createSourceFixture(path.Join(pwd, "config"), "config.go", `package config
+import "github.com/pelletier/go-toml"
+`)
+
+	createSourceFixture(path.Join(pwd, "deps"), "model.go", `package deps
+import "github.com/d2fn/gopack/config"
+import "github.com/pelletier/go-toml"
+`)
+
+	createSourceFixture(path.Join(pwd, "deps"), "graph.go", `package deps
+import "github.com/pelletier/go-toml"
+`)
+
+	stats, err := Analyze(pwd, Options{Repository: "github.com/d2fn/gopack"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	packages := stats.Packages()
+	if len(packages) != 3 {
+		t.Fatalf("Expected 3 packages but found %d\n", len(packages))
+	}
+
+	for i, expected := range []struct {
+		dir           string
+		fanIn, fanOut int
+		deps, single  int
+	}{
+		{".", 0, 3, 1, 1},
+		{"config", 2, 1, 1, 1},
+		{"deps", 1, 2, 1, 0},
+	} {
+		p := packages[i]
+		if p.Dir != expected.dir || p.FanIn != expected.fanIn || p.FanOut() != expected.fanOut {
+			t.Errorf("Expected %s to have fan-in %d and fan-out %d but it was %s with %d and %d\n", expected.dir, expected.fanIn, expected.fanOut, p.Dir, p.FanIn, p.FanOut())
+		}
+		if len(p.Deps) != expected.deps || len(p.SingleFileDeps()) != expected.single {
+			t.Errorf("Expected %s to have %d remote imports, %d from a single file, but it was %v and %v\n", p.Dir, expected.deps, expected.single, p.Deps, p.SingleFileDeps())
+		}
+	}
+
+	coupling := stats.Coupling()
+	if len(coupling) != 2 || coupling[0].Path != "github.com/pelletier/go-toml" || coupling[0].Packages != 2 || coupling[0].References != 3 {
+		t.Errorf("Expected go-toml to be the most coupled dependency but it was %v\n", coupling)
+	}
+
+	var out bytes.Buffer
+	stats.PrintPackageSummary(&out)
+	if !hasLine(out.String(), "config", "2", "1", "1") || !hasLine(out.String(), "github.com/pelletier/go-toml", "2", "3") {
+		t.Errorf("Expected the package summary to list config and go-toml but it was\n%s", out.String())
+	}
+}
+
+// Whether a line of printed output is made of the fields, however aligned.
+func hasLine(output string, fields ...string) bool {
+	for _, line := range strings.Split(output, "\n") {
+		if strings.Join(strings.Fields(line), " ") == strings.Join(fields, " ") {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSymbols(t *testing.T) {
+	pwd := setupTestDir()
+
+	createSourceFixture(pwd, "foo.go", `package main
+import (
+	"fmt"
+	"github.com/gorilla/mux"
+	t "github.com/pelletier/go-toml"
+	. "launchpad.net/gocheck"
+	"gopkg.in/yaml.v2"
+)
+
+func main() {
+	r := mux.NewRouter()
+	r.HandleFunc("/", nil)
+	tree, _ := t.Load("")
+	fmt.Println(mux.Vars(nil), mux.Vars(nil), tree, Equals, yaml.Marshal)
+}
+`)
+
+	stats, err := Analyze(pwd, Options{Symbols: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for importPath, expected := range map[string]map[string]int{
+		"github.com/gorilla/mux":       {"NewRouter": 1, "Vars": 2},
+		"github.com/pelletier/go-toml": {"Load": 1},
+		"launchpad.net/gocheck":        {"Equals": 1},
+		"gopkg.in/yaml.v2":             {"Marshal": 1},
+		"fmt":                          {"Println": 1},
+	} {
+		symbols := stats.ImportStatsByPath[importPath].Symbols
+		if len(symbols) != len(expected) {
+			t.Errorf("Expected %s symbols to be %v but they were %v\n", importPath, expected, symbols)
+			continue
+		}
+		for name, n := range expected {
+			if symbols[name] != n {
+				t.Errorf("Expected %s symbols to be %v but they were %v\n", importPath, expected, symbols)
+			}
+		}
+	}
+
+	counts := stats.ImportStatsByPath["github.com/gorilla/mux"].SymbolCounts()
+	if counts[0].Name != "Vars" || counts[1].Name != "NewRouter" {
+		t.Errorf("Expected the most used symbols first but they were %v\n", counts)
+	}
+
+	var out bytes.Buffer
+	stats.PrintSymbolSummary(&out)
+	if !hasLine(out.String(), "github.com/gorilla/mux", "2", "symbols", "3", "references") || !hasLine(out.String(), "Vars", "2") {
+		t.Errorf("Expected the symbol summary to list the mux symbols but it was\n%s", out.String())
+	}
+	if hasLine(out.String(), "fmt", "1", "symbols", "1", "references") {
+		t.Errorf("Expected the symbol summary to leave out the standard library but it was\n%s", out.String())
+	}
+}
+
+func TestPackageName(t *testing.T) {
+	for importPath, expected := range map[string]string{
+		"fmt":                          "fmt",
+		"github.com/pelletier/go-toml": "toml",
+		"gopkg.in/yaml.v2":             "yaml",
+		"example.com/foo/v2":           "foo",
+		"github.com/mattn/go-sqlite3":  "sqlite3",
+		"github.com/jinzhu/inflection": "inflection",
+	} {
+		if name := packageName(importPath); name != expected {
+			t.Errorf("Expected %s to be named %s but it was %s\n", importPath, expected, name)
+		}
+	}
+}
+
+func TestStdlibPackages(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+
+	std := stdlibPackages(nil)
+	if !std["net/http/httptest"] || !std["go/build/constraint"] {
+		t.Error("Expected the packages go list std lists")
+	}
+	if std["cmd/go"] {
+		t.Error("Expected the commands to be left out")
+	}
+}
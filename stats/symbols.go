@@ -0,0 +1,136 @@
+package stats
+
+import (
+	"fmt"
+	"go/ast"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// How often an exported identifier of an import is referenced.
+type SymbolCount struct {
+	Name  string
+	Count int
+}
+
+// Exported identifiers of the import referenced in the source, most used first.
+// Only collected when the analysis is run with Options.Symbols.
+func (i *ImportStats) SymbolCounts() []SymbolCount {
+	counts := make([]SymbolCount, 0, len(i.Symbols))
+	for name, n := range i.Symbols {
+		counts = append(counts, SymbolCount{Name: name, Count: n})
+	}
+	sort.Slice(counts, func(a, b int) bool {
+		if counts[a].Count != counts[b].Count {
+			return counts[a].Count > counts[b].Count
+		}
+		return counts[a].Name < counts[b].Name
+	})
+	return counts
+}
+
+var (
+	majorVersion  = regexp.MustCompile(`^v[0-9]+$`)
+	versionSuffix = regexp.MustCompile(`\.v[0-9]+$`)
+)
+
+// Guess the name a package is declared with from its import path,
+// the way most of them are named: gopkg.in/yaml.v2 is yaml,
+// github.com/pelletier/go-toml is toml and example.com/foo/v2 is foo.
+func packageName(importPath string) string {
+	parts := strings.Split(importPath, "/")
+	name := parts[len(parts)-1]
+	if majorVersion.MatchString(name) && len(parts) > 1 {
+		name = parts[len(parts)-2]
+	}
+	name = versionSuffix.ReplaceAllString(name, "")
+	name = strings.TrimPrefix(name, "go-")
+	name = strings.TrimSuffix(name, "-go")
+	return strings.Replace(name, "-", "_", -1)
+}
+
+// Count the exported identifiers referenced through every import of the
+// file, resolving the names imports are renamed to and dot imports.
+func fileSymbols(f *ast.File) (map[string]map[string]int, error) {
+	symbols := make(map[string]map[string]int)
+	names := make(map[string]string)
+	dots := []string{}
+	for _, i := range f.Imports {
+		importPath, err := strconv.Unquote(i.Path.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		name := packageName(importPath)
+		if i.Name != nil {
+			name = i.Name.Name
+		}
+		switch name {
+		case "_":
+		case ".":
+			dots = append(dots, importPath)
+		default:
+			names[name] = importPath
+		}
+	}
+
+	count := func(importPath, symbol string) {
+		if symbols[importPath] == nil {
+			symbols[importPath] = make(map[string]int)
+		}
+		symbols[importPath][symbol]++
+	}
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		// a package name is never declared in the file
+		if x, ok := sel.X.(*ast.Ident); ok && x.Obj == nil && sel.Sel.IsExported() {
+			if importPath, found := names[x.Name]; found {
+				count(importPath, sel.Sel.Name)
+			}
+		}
+		return true
+	})
+
+	// identifiers of a dot import can't be told apart
+	// from those of another one, nor from universe ones
+	if len(dots) == 1 {
+		for _, ident := range f.Unresolved {
+			if _, isPackage := names[ident.Name]; ident.IsExported() && !isPackage {
+				count(dots[0], ident.Name)
+			}
+		}
+	}
+	return symbols, nil
+}
+
+func (ps *ProjectStats) PrintSymbolSummary(w io.Writer) {
+	writer := tabwriter.NewWriter(w, 0, 8, 1, '\t', 0)
+	summary := ps.GetSummary()
+
+	fmt.Fprint(writer, "Symbol usage summary:\n\n")
+	for _, item := range summary.Items {
+		s := ps.ImportStatsByPath[item.Path]
+		if !s.Remote {
+			continue
+		}
+
+		counts := s.SymbolCounts()
+		references := 0
+		for _, c := range counts {
+			references += c.Count
+		}
+		fmt.Fprintf(writer, "%s\t%d symbols\t%d references\n", s.Path, len(counts), references)
+		for _, c := range counts {
+			fmt.Fprintf(writer, "  %s\t%d\n", c.Name, c.Count)
+		}
+	}
+	writer.Flush()
+}
@@ -0,0 +1,114 @@
+// Package workspace reads gopack.workspace files, which list local
+// projects developed side by side that share one vendor tree.
+package workspace
+
+import (
+	"fmt"
+	"github.com/d2fn/gopack"
+	"github.com/d2fn/gopack/config"
+	"github.com/pelletier/go-toml"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type Workspace struct {
+	// Directory holding gopack.workspace, whose vendor tree the projects share.
+	Root string
+	// Configuration of every project, in the order they are listed.
+	Configs []*config.Config
+}
+
+// Find the workspace dir belongs to, looking for gopack.workspace in dir
+// and its parents. It returns nil when there's none, or when it doesn't
+// list the project in dir.
+func Find(dir string) (*Workspace, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for root := dir; ; root = filepath.Dir(root) {
+		if _, err := os.Stat(filepath.Join(root, gopack.WorkspaceFile)); err == nil {
+			w, err := Load(root)
+			if err != nil || w.Config(dir) == nil {
+				return nil, err
+			}
+			return w, nil
+		}
+		if filepath.Dir(root) == root {
+			return nil, nil
+		}
+	}
+}
+
+// Load the workspace at root and the configuration of its projects,
+// each of which must name its repository to be linked in the vendor tree.
+func Load(root string) (*Workspace, error) {
+	path := filepath.Join(root, gopack.WorkspaceFile)
+	dat, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, WorkspaceError(path, err)
+	}
+	t, err := toml.Load(string(dat))
+	if err != nil {
+		return nil, WorkspaceError(path, err)
+	}
+
+	for _, k := range t.Keys() {
+		if k != "projects" {
+			return nil, WorkspaceError(path, fmt.Errorf("unknown key %s", k))
+		}
+	}
+	projects, ok := t.Get("projects").([]interface{})
+	if !ok {
+		return nil, WorkspaceError(path, fmt.Errorf("projects must be an array of strings"))
+	}
+
+	w := &Workspace{Root: root}
+	repos := make(map[string]string)
+	for _, p := range projects {
+		dir, ok := p.(string)
+		if !ok {
+			return nil, WorkspaceError(path, fmt.Errorf("projects must be an array of strings"))
+		}
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(root, filepath.FromSlash(dir))
+		}
+
+		c, err := config.NewConfig(dir)
+		if err != nil {
+			return nil, err
+		}
+		if c.Repository == "" {
+			return nil, WorkspaceError(path, fmt.Errorf("%s doesn't name its repo", c.Path))
+		}
+		if other, found := repos[c.Repository]; found {
+			return nil, WorkspaceError(path, fmt.Errorf("%s is the repo of both %s and %s", c.Repository, other, dir))
+		}
+		repos[c.Repository] = dir
+
+		// every project keeps its own checksum in the shared vendor tree
+		c.ChecksumFile = filepath.Join(gopack.GopackChecksums, strings.Replace(c.Repository, "/", "_", -1))
+		w.Configs = append(w.Configs, c)
+	}
+	return w, nil
+}
+
+// The configuration of the project in dir, nil if it's not in the workspace.
+func (w *Workspace) Config(dir string) *config.Config {
+	for _, c := range w.Configs {
+		if filepath.Dir(c.Path) == filepath.Clean(dir) {
+			return c
+		}
+	}
+	return nil
+}
+
+func WorkspaceError(path string, err error) *gopack.ProjectError {
+	return &gopack.ProjectError{
+		Kind:    gopack.InvalidWorkspace,
+		Message: fmt.Sprintf("%s: %s\n", path, err),
+	}
+}
@@ -0,0 +1,97 @@
+package workspace
+
+import (
+	"github.com/d2fn/gopack"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func check(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
+
+func writeFile(path, content string) {
+	check(os.MkdirAll(filepath.Dir(path), 0755))
+	check(ioutil.WriteFile(path, []byte(content), 0644))
+}
+
+// A workspace listing the projects, each given as its directory and repo.
+func setupTestWorkspace(projects ...string) string {
+	root, err := ioutil.TempDir("", "gopack-workspace-")
+	check(err)
+
+	list := ""
+	for i := 0; i < len(projects); i += 2 {
+		writeFile(filepath.Join(root, projects[i], gopack.ConfigFile), `repo = "`+projects[i+1]+`"`)
+		list += `"` + projects[i] + `", `
+	}
+	writeFile(filepath.Join(root, gopack.WorkspaceFile), "projects = ["+list+"]\n")
+	return root
+}
+
+func TestLoad(t *testing.T) {
+	root := setupTestWorkspace("services/api", "github.com/acme/api", "libs/auth", "github.com/acme/auth")
+
+	w, err := Load(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(w.Configs) != 2 || w.Configs[0].Repository != "github.com/acme/api" || w.Configs[1].Repository != "github.com/acme/auth" {
+		t.Fatalf("Expected the projects in the order they are listed but found %v\n", w.Configs)
+	}
+	if checksum := w.Configs[1].ChecksumFile; checksum != filepath.Join(gopack.GopackChecksums, "github.com_acme_auth") {
+		t.Errorf("Expected every project to have its own checksum but it was %s\n", checksum)
+	}
+}
+
+func TestLoadWithoutRepo(t *testing.T) {
+	root := setupTestWorkspace("api", "github.com/acme/api")
+	writeFile(filepath.Join(root, "auth", gopack.ConfigFile), "")
+	writeFile(filepath.Join(root, gopack.WorkspaceFile), `projects = ["api", "auth"]`)
+
+	_, err := Load(root)
+	if e, ok := err.(*gopack.ProjectError); !ok || e.Kind != gopack.InvalidWorkspace {
+		t.Fatalf("Expected an invalid workspace error but it was %v\n", err)
+	}
+}
+
+func TestLoadDuplicateRepo(t *testing.T) {
+	root := setupTestWorkspace("api", "github.com/acme/api", "fork", "github.com/acme/api")
+
+	_, err := Load(root)
+	if e, ok := err.(*gopack.ProjectError); !ok || e.Kind != gopack.InvalidWorkspace {
+		t.Fatalf("Expected an invalid workspace error but it was %v\n", err)
+	}
+}
+
+func TestLoadUnknownKey(t *testing.T) {
+	root := setupTestWorkspace("api", "github.com/acme/api")
+	writeFile(filepath.Join(root, gopack.WorkspaceFile), "projects = [\"api\"]\nvendor = \"shared\"\n")
+
+	_, err := Load(root)
+	if e, ok := err.(*gopack.ProjectError); !ok || e.Kind != gopack.InvalidWorkspace {
+		t.Fatalf("Expected an invalid workspace error but it was %v\n", err)
+	}
+}
+
+func TestFind(t *testing.T) {
+	root := setupTestWorkspace("services/api", "github.com/acme/api")
+	writeFile(filepath.Join(root, "tools", gopack.ConfigFile), `repo = "github.com/acme/tools"`)
+
+	w, err := Find(filepath.Join(root, "services", "api"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w == nil || w.Root != root {
+		t.Fatalf("Expected to find the workspace in %s but it was %v\n", root, w)
+	}
+
+	w, err = Find(filepath.Join(root, "tools"))
+	if err != nil || w != nil {
+		t.Errorf("Expected a project the workspace doesn't list to be on its own but found %v, %v\n", w, err)
+	}
+}